@@ -0,0 +1,105 @@
+// SPDX-FileCopyrightText: 2023 Steffen Vogel <post@steffenvogel.de>
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"cunicu.li/gont/v2/internal"
+	g "cunicu.li/gont/v2/pkg"
+	co "cunicu.li/gont/v2/pkg/options/capture"
+	"golang.org/x/exp/slices"
+)
+
+var errInvalidCaptureTarget = errors.New("invalid capture target")
+
+func capture(args []string) error {
+	fs := flag.NewFlagSet("capture", flag.ContinueOnError)
+	filename := fs.String("w", "-", "write packets to a PCAPng file or stdout")
+	filter := fs.String("f", "", "pcap-filter(7) expression")
+
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	if fs.NArg() < 1 {
+		return errNotEnoughArguments
+	}
+
+	network, node, intf, err := networkNodeInterface(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	// Flags can also follow the target
+	if err := fs.Parse(fs.Args()[1:]); err != nil {
+		return err
+	}
+
+	signals := internal.SetupSignals()
+
+	opts := []g.CaptureOption{
+		co.FilterExpression(*filter),
+	}
+
+	if *filename == "-" {
+		opts = append(opts, co.ToFile(os.Stdout))
+	} else {
+		opts = append(opts, co.Filename(*filename))
+	}
+
+	c := g.NewCapture(opts...)
+
+	// Stream each packet to allow piping into Wireshark
+	c.FlushEach = 1
+
+	intfs := []string{}
+	if intf != "" {
+		intfs = append(intfs, intf)
+	}
+
+	if err := c.AttachNode(network, node, intfs...); err != nil {
+		return err
+	}
+
+	<-signals
+
+	if err := c.Close(); err != nil {
+		return err
+	}
+
+	stats := c.Stats()
+	fmt.Fprintf(os.Stderr, "%d packets captured\n", stats.PacketsCaptured)
+	fmt.Fprintf(os.Stderr, "%d packets dropped\n", stats.PacketsDropped)
+
+	return nil
+}
+
+// networkNodeInterface parses a target in the form <network>/<node>[/<interface>].
+func networkNodeInterface(target string) (network, node, intf string, err error) {
+	c := strings.Split(target, "/")
+
+	switch len(c) {
+	case 2:
+		network, node = c[0], c[1]
+	case 3:
+		network, node, intf = c[0], c[1], c[2]
+	default:
+		return "", "", "", fmt.Errorf("%w: %s", errInvalidCaptureTarget, target)
+	}
+
+	if !slices.Contains(g.NetworkNames(), network) {
+		return "", "", "", fmt.Errorf("%w '%s'", errNoSuchNetwork, network)
+	}
+
+	if !slices.Contains(g.NodeNames(network), node) {
+		return "", "", "", fmt.Errorf("%w '%s' in network '%s'", errNoSuchNode, node, network)
+	}
+
+	return network, node, intf, nil
+}
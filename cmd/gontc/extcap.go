@@ -0,0 +1,239 @@
+// SPDX-FileCopyrightText: 2023 Steffen Vogel <post@steffenvogel.de>
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"cunicu.li/gont/v2/internal"
+	g "cunicu.li/gont/v2/pkg"
+	co "cunicu.li/gont/v2/pkg/options/capture"
+	"github.com/gopacket/gopacket/layers"
+	"go.uber.org/zap"
+	"golang.org/x/exp/slices"
+)
+
+const extcapTraceInterface = "tracer"
+
+var (
+	errMissingExtcapInterface = errors.New("missing --extcap-interface")
+	errMissingExtcapFifo      = errors.New("missing --fifo")
+	errMissingExtcapOperation = errors.New("missing extcap operation")
+	errInvalidExtcapInterface = errors.New("invalid extcap interface")
+)
+
+// extcap implements the Wireshark extcap interface.
+// See: https://www.wireshark.org/docs/wsdg_html_chunked/ChCaptureExtcap.html
+func extcap(args []string) error {
+	fs := flag.NewFlagSet("extcap", flag.ContinueOnError)
+
+	listInterfaces := fs.Bool("extcap-interfaces", false, "list the interfaces of all Gont networks")
+	listDLTs := fs.Bool("extcap-dlts", false, "list the link-layer types of an interface")
+	listConfig := fs.Bool("extcap-config", false, "list the configuration options of an interface")
+	capture := fs.Bool("capture", false, "start capturing")
+	intf := fs.String("extcap-interface", "", "interface to operate on")
+	fifo := fs.String("fifo", "", "named pipe to which the captured packets are written")
+	filter := fs.String("extcap-capture-filter", "", "pcap-filter(7) expression")
+	snapLen := fs.Int("snaplen", 1600, "snapshot length")
+	promisc := fs.Bool("promiscuous", false, "enable promiscuous mode")
+
+	// Options passed by Wireshark which we do not use
+	fs.String("extcap-version", "", "version of Wireshark")
+	fs.String("extcap-control-in", "", "control pipe from Wireshark")
+	fs.String("extcap-control-out", "", "control pipe to Wireshark")
+	fs.Bool("debug", false, "enable debug output")
+	fs.String("debug-file", "", "file for debug output")
+
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	switch {
+	case *listInterfaces:
+		return extcapInterfaces()
+
+	case *intf == "":
+		return errMissingExtcapInterface
+
+	case *listDLTs:
+		return extcapDLTs(*intf)
+
+	case *listConfig:
+		return extcapConfig(*intf)
+
+	case *capture:
+		if *fifo == "" {
+			return errMissingExtcapFifo
+		}
+
+		network, node, name, err := extcapInterface(*intf)
+		if err != nil {
+			return err
+		}
+
+		if node == "" {
+			return extcapCaptureTrace(network, *fifo)
+		}
+
+		return extcapCapture(network, node, name, *fifo,
+			co.FilterExpression(*filter),
+			co.SnapshotLength(*snapLen),
+			co.Promiscuous(*promisc),
+		)
+
+	default:
+		return errMissingExtcapOperation
+	}
+}
+
+func extcapInterfaces() error {
+	version := "unknown"
+	if tag != "" {
+		version = tag
+	}
+
+	fmt.Printf("extcap {version=%s}{help=https://cunicu.li/gont}\n", version)
+
+	for _, network := range g.NetworkNames() {
+		for _, node := range g.NodeNames(network) {
+			intfs, err := g.InterfaceNames(network, node)
+			if err != nil {
+				zap.L().Warn("Failed to list interfaces",
+					zap.String("network", network),
+					zap.String("node", node),
+					zap.Error(err))
+				continue
+			}
+
+			for _, intf := range intfs {
+				name := fmt.Sprintf("%s/%s/%s", network, node, intf)
+				fmt.Printf("interface {value=%s}{display=Gont: %s}\n", name, name)
+			}
+		}
+
+		if g.TraceExposed(network) {
+			name := fmt.Sprintf("%s/%s", network, extcapTraceInterface)
+			fmt.Printf("interface {value=%s}{display=Gont: %s trace events}\n", name, network)
+		}
+	}
+
+	return nil
+}
+
+func extcapDLTs(intf string) error {
+	network, node, name, err := extcapInterface(intf)
+	if err != nil {
+		return err
+	}
+
+	if node == "" {
+		fmt.Printf("dlt {number=%d}{name=USER0}{display=Gont trace events}\n", g.LinkTypeTrace)
+		return nil
+	}
+
+	linkType, err := g.InterfaceLinkType(network, node, name)
+	if err != nil {
+		return err
+	}
+
+	switch linkType {
+	case layers.LinkTypeEthernet:
+		fmt.Printf("dlt {number=%d}{name=EN10MB}{display=Ethernet}\n", linkType)
+	case layers.LinkTypeRaw:
+		// Tunnel interfaces like WireGuard or GRE carry raw IP packets
+		fmt.Printf("dlt {number=%d}{name=RAW}{display=Raw IP}\n", linkType)
+	default:
+		fmt.Printf("dlt {number=%d}{name=%s}{display=%s}\n", linkType, linkType, linkType)
+	}
+
+	return nil
+}
+
+func extcapConfig(intf string) error {
+	_, node, _, err := extcapInterface(intf)
+	if err != nil {
+		return err
+	}
+
+	// The trace events can not be configured
+	if node == "" {
+		return nil
+	}
+
+	fmt.Println("arg {number=0}{call=--snaplen}{display=Snapshot length}{type=integer}{range=64,262144}{default=1600}")
+	fmt.Println("arg {number=1}{call=--promiscuous}{display=Promiscuous mode}{type=boolflag}")
+
+	return nil
+}
+
+// extcapInterface parses an interface name as returned by extcapInterfaces().
+// The node is empty for the trace pseudo-interface.
+func extcapInterface(intf string) (network, node, name string, err error) {
+	c := strings.Split(intf, "/")
+
+	switch {
+	case len(c) == 2 && c[1] == extcapTraceInterface:
+		if network = c[0]; !slices.Contains(g.NetworkNames(), network) {
+			return "", "", "", fmt.Errorf("%w '%s'", errNoSuchNetwork, network)
+		}
+
+		return network, "", "", nil
+
+	case len(c) == 3:
+		return networkNodeInterface(intf)
+
+	default:
+		return "", "", "", fmt.Errorf("%w: %s", errInvalidExtcapInterface, intf)
+	}
+}
+
+func extcapCapture(network, node, intf, fifo string, opts ...g.CaptureOption) error {
+	// Wireshark stops the capture via SIGTERM
+	signals := internal.SetupSignals()
+
+	c := g.NewCapture(append(opts, co.ToPipename(fifo))...)
+
+	if err := c.AttachNode(network, node, intf); err != nil {
+		return err
+	}
+
+	<-signals
+
+	return c.Close()
+}
+
+func extcapCaptureTrace(network, fifo string) error {
+	signals := internal.SetupSignals()
+
+	conn, err := g.DialTrace(network)
+	if err != nil {
+		return fmt.Errorf("failed to connect to tracer: %w", err)
+	}
+	defer conn.Close()
+
+	f, err := os.OpenFile(fifo, os.O_WRONLY, 0)
+	if err != nil {
+		return fmt.Errorf("failed to open fifo: %w", err)
+	}
+	defer f.Close()
+
+	done := make(chan error, 1)
+
+	go func() {
+		_, err := io.Copy(f, conn)
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-signals:
+		return nil
+	}
+}
@@ -0,0 +1,68 @@
+// SPDX-FileCopyrightText: 2023 Steffen Vogel <post@steffenvogel.de>
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"compress/gzip"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	g "cunicu.li/gont/v2/pkg"
+	"github.com/klauspost/compress/zstd"
+)
+
+var errMissingFilename = errors.New("missing filename")
+
+func flows(args []string) error {
+	fs := flag.NewFlagSet("flows", flag.ContinueOnError)
+	asJSON := fs.Bool("json", false, "print flows as JSON")
+
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	if fs.NArg() < 1 {
+		return errMissingFilename
+	}
+
+	filename := fs.Arg(0)
+
+	f, err := os.Open(filename)
+	if err != nil {
+		return fmt.Errorf("failed to open file: %w", err)
+	}
+	defer f.Close()
+
+	var rd io.Reader = f
+
+	switch _, compression := g.CaptureFormatFromFilename(filename); compression {
+	case g.CaptureCompressionGzip:
+		if rd, err = gzip.NewReader(f); err != nil {
+			return fmt.Errorf("failed to open gzip stream: %w", err)
+		}
+
+	case g.CaptureCompressionZstd:
+		zrd, err := zstd.NewReader(f)
+		if err != nil {
+			return fmt.Errorf("failed to open zstd stream: %w", err)
+		}
+		defer zrd.Close()
+
+		rd = zrd
+	}
+
+	fls, err := g.ReadFlows(rd)
+	if err != nil {
+		return err
+	}
+
+	if *asJSON {
+		return fls.WriteJSON(os.Stdout)
+	}
+
+	return fls.WriteTable(os.Stdout)
+}
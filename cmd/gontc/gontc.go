@@ -40,6 +40,10 @@ func usage() {
 	fmt.Fprintln(w, "   exec  [<network>]/<node> <command> [args]    executes a <command> in the namespace of <node> with optional [args]")
 	fmt.Fprintln(w, "   list  [<network>]                            list all active Gont networks or nodes of a given network")
 	fmt.Fprintln(w, "   clean [<network>]                            removes the all or just the specified Gont network")
+	fmt.Fprintln(w, "   capture <network>/<node>[/<intf>] [-w file] [-f bpf]")
+	fmt.Fprintln(w, "                                                capture packets on one or all interfaces of <node> to a PCAPng file or stdout")
+	fmt.Fprintln(w, "   flows [-json] <file>                         print a summary of the flows in a PCAPng <file>")
+	fmt.Fprintln(w, "   extcap [options]                             implements the Wireshark extcap interface for capturing live networks")
 	fmt.Fprintln(w, "   help                                         show this usage information")
 	fmt.Fprintln(w, "   version                                      shows the version of Gont")
 	// fmt.Fprintln(w)
@@ -61,11 +65,6 @@ func run() int {
 	logger := internal.SetupLogging()
 	defer logger.Sync() //nolint:errcheck
 
-	if err := g.CheckCaps(); err != nil {
-		fmt.Printf("error: %s\n", err)
-		return -1
-	}
-
 	flag.Usage = usage
 	flag.Parse()
 
@@ -77,6 +76,14 @@ func run() int {
 	args := flag.Args()
 	subcmd := args[0]
 
+	// Summarizing flows only reads a file and hence does not require any privileges
+	if subcmd != "flows" {
+		if err := g.CheckCaps(); err != nil {
+			fmt.Printf("error: %s\n", err)
+			return -1
+		}
+	}
+
 	switch subcmd {
 	case "shell":
 		if network, node, err = networkNode(args); err == nil {
@@ -94,6 +101,15 @@ func run() int {
 	case "list":
 		list(args)
 
+	case "capture":
+		err = capture(args)
+
+	case "flows":
+		err = flows(args)
+
+	case "extcap":
+		err = extcap(args)
+
 	case "identify":
 		if network, node, err = g.Identify(); err == nil {
 			fmt.Printf("%s/%s\n", network, node)
@@ -10,7 +10,19 @@ import (
 	"net"
 )
 
-var errUnsupportedAddressLength = errors.New("unsupported address length")
+var (
+	errUnsupportedAddressLength = errors.New("unsupported address length")
+	errUnsupportedAddressType   = errors.New("unsupported address type")
+	errInvalidAddress           = errors.New("failed to parse address")
+	errNoAddress                = errors.New("node has no address")
+)
+
+// AddressLookuper is implemented by all nodes which have
+// IP addresses assigned (Host, Router, NAT).
+type AddressLookuper interface {
+	Name() string
+	LookupAddresses(n string) []*net.IPAddr
+}
 
 func ipToInt(ip net.IP) (*big.Int, int) {
 	val := &big.Int{}
@@ -60,3 +72,52 @@ func AddressRange(network *net.IPNet) (net.IP, net.IP) {
 
 	return firstIP, intToIP(lastIPInt, bits)
 }
+
+// Prefixes converts an address into prefixes.
+//
+// The address can be a *net.IPNet, net.IP, a string in CIDR or plain IP notation
+// or an AddressLookuper whose addresses of the network "ip", "ip4" or "ip6" are returned.
+func Prefixes(addr any, network string) ([]*net.IPNet, error) {
+	switch addr := addr.(type) {
+	case *net.IPNet:
+		return []*net.IPNet{addr}, nil
+	case net.IP:
+		return []*net.IPNet{HostPrefix(addr)}, nil
+	case string:
+		if _, n, err := net.ParseCIDR(addr); err == nil {
+			return []*net.IPNet{n}, nil
+		} else if ip := net.ParseIP(addr); ip != nil {
+			return []*net.IPNet{HostPrefix(ip)}, nil
+		}
+
+		return nil, fmt.Errorf("%w: %s", errInvalidAddress, addr)
+	case AddressLookuper:
+		netws := []*net.IPNet{}
+		for _, ip := range addr.LookupAddresses(network) {
+			netws = append(netws, HostPrefix(ip.IP))
+		}
+
+		if len(netws) == 0 {
+			return nil, fmt.Errorf("%w: %s", errNoAddress, addr.Name())
+		}
+
+		return netws, nil
+	default:
+		return nil, fmt.Errorf("%w: %T", errUnsupportedAddressType, addr)
+	}
+}
+
+// HostPrefix returns a prefix which only contains the given address.
+func HostPrefix(ip net.IP) *net.IPNet {
+	if ip4 := ip.To4(); ip4 != nil {
+		return &net.IPNet{
+			IP:   ip4,
+			Mask: net.CIDRMask(8*net.IPv4len, 8*net.IPv4len),
+		}
+	}
+
+	return &net.IPNet{
+		IP:   ip,
+		Mask: net.CIDRMask(8*net.IPv6len, 8*net.IPv6len),
+	}
+}
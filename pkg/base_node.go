@@ -20,7 +20,10 @@ import (
 	"golang.org/x/sys/unix"
 )
 
-var ErrInvalidName = errors.New("invalid name")
+var (
+	ErrInvalidName = errors.New("invalid name")
+	errNoRoute     = errors.New("no route to destination")
+)
 
 type BaseNodeOption interface {
 	ApplyBaseNode(n *BaseNode)
@@ -48,6 +51,11 @@ type BaseNode struct {
 	EmptyDirs                []string
 	Captures                 []*Capture
 
+	// RoutingTables maps the IDs of routing tables to names which
+	// are only visible within the node. They take precedence over
+	// the network-wide names in Network.RoutingTables.
+	RoutingTables map[uint32]string
+
 	logger *zap.Logger
 }
 
@@ -75,6 +83,8 @@ func (n *Network) AddNode(name string, opts ...Option) (node *BaseNode, err erro
 		VarPath: basePath,
 		Slice:   fmt.Sprintf("%s-%s", n.Slice, name),
 		logger:  zap.L().Named("node").With(zap.String("node", name)),
+
+		RoutingTables: map[uint32]string{},
 	}
 
 	node.logger.Info("Adding new node")
@@ -396,28 +406,96 @@ func (n *BaseNode) EnableForwarding() error {
 }
 
 // AddRoute adds a route to the node.
-func (n *BaseNode) AddRoute(r *nl.Route) error {
+//
+// Options like a VRF or a routing table can be passed
+// to add the route to a table other than the main one.
+func (n *BaseNode) AddRoute(r *nl.Route, opts ...Option) error {
+	applyRouteOptions(r, opts...)
+
 	n.logger.Info("Add route",
 		zap.Any("dst", r.Dst),
 		zap.Any("gw", r.Gw),
+		zap.Int("table", r.Table),
 	)
 
 	return n.nlHandle.RouteAdd(r)
 }
 
 // AddDefaultRoute adds a default route for this node by providing a default gateway.
-func (n *BaseNode) AddDefaultRoute(gw net.IP) error {
+func (n *BaseNode) AddDefaultRoute(gw net.IP, opts ...Option) error {
 	if gw.To4() != nil {
 		return n.AddRoute(&nl.Route{
 			Dst: &DefaultIPv4Mask,
 			Gw:  gw,
-		})
+		}, opts...)
 	}
 
 	return n.AddRoute(&nl.Route{
 		Dst: &DefaultIPv6Mask,
 		Gw:  gw,
-	})
+	}, opts...)
+}
+
+// DeleteRoute deletes a route from the node.
+func (n *BaseNode) DeleteRoute(r *nl.Route, opts ...Option) error {
+	applyRouteOptions(r, opts...)
+
+	n.logger.Info("Delete route",
+		zap.Any("dst", r.Dst),
+		zap.Any("gw", r.Gw),
+		zap.Int("table", r.Table),
+	)
+
+	return n.nlHandle.RouteDel(r)
+}
+
+// ReplaceRoute adds a route to the node or replaces an existing one with the same destination.
+func (n *BaseNode) ReplaceRoute(r *nl.Route, opts ...Option) error {
+	applyRouteOptions(r, opts...)
+
+	n.logger.Info("Replace route",
+		zap.Any("dst", r.Dst),
+		zap.Any("gw", r.Gw),
+		zap.Int("table", r.Table),
+	)
+
+	return n.nlHandle.RouteReplace(r)
+}
+
+// RouteList returns all routes of the given address family (e.g. nl.FAMILY_V4 or nl.FAMILY_ALL)
+//
+// By default, only the routes of the main table are returned.
+// Options like a VRF or a routing table can be passed to select another table.
+func (n *BaseNode) RouteList(family int, opts ...Option) ([]nl.Route, error) {
+	filter := &nl.Route{}
+	applyRouteOptions(filter, opts...)
+
+	var mask uint64
+	if filter.Table != 0 {
+		mask |= nl.RT_FILTER_TABLE
+	}
+
+	return n.nlHandle.RouteListFiltered(family, filter, mask)
+}
+
+// RouteGet returns the route which the kernel would select for packets towards dst.
+func (n *BaseNode) RouteGet(dst net.IP) (*nl.Route, error) {
+	routes, err := n.nlHandle.RouteGet(dst)
+	if err != nil {
+		return nil, err
+	} else if len(routes) == 0 {
+		return nil, errNoRoute
+	}
+
+	return &routes[0], nil
+}
+
+func applyRouteOptions(r *nl.Route, opts ...Option) {
+	for _, opt := range opts {
+		if opt, ok := opt.(RouteOption); ok {
+			opt.ApplyRoute(r)
+		}
+	}
 }
 
 // AddInterface adds an interface to the list of configured interfaces
@@ -0,0 +1,129 @@
+// SPDX-FileCopyrightText: 2023 Steffen Vogel <post@steffenvogel.de>
+// SPDX-License-Identifier: Apache-2.0
+
+package gont
+
+import (
+	"errors"
+	"fmt"
+	"syscall"
+
+	nl "github.com/vishvananda/netlink"
+	"go.uber.org/zap"
+)
+
+// DefaultBondMIIMonitorInterval is the interval in milliseconds
+// in which the link state of bond members is checked.
+const DefaultBondMIIMonitorInterval = 100
+
+var (
+	errNoBond       = errors.New("interface is not a bond")
+	errNoBondMember = errors.New("interface is not a bond member")
+	errNoMembers    = errors.New("bond has no members")
+	errNoActive     = errors.New("bond has no active member")
+)
+
+type BondOption interface {
+	ApplyBond(b *nl.Bond)
+}
+
+// AddBond creates the bond interface i and links all of its members
+// to the nodes which have been configured as their peers.
+//
+// As a single peer node might be connected to multiple members of the bond,
+// the peer interfaces are named after the host and the member interface
+// (e.g. h1-veth0).
+func (h *Host) AddBond(i *Interface) error {
+	if len(i.Name) > syscall.IFNAMSIZ-1 {
+		return fmt.Errorf("%w: too long. max_len=%d", ErrInvalidName, syscall.IFNAMSIZ-1)
+	}
+
+	if len(i.BondMembers) == 0 {
+		return errNoMembers
+	}
+
+	h.logger.Info("Adding new bond",
+		zap.Any("intf", i),
+		zap.String("mode", i.Bond.Mode.String()),
+		zap.Int("members", len(i.BondMembers)),
+	)
+
+	i.Node = h
+	i.Bond.Name = i.Name
+
+	if i.Bond.Miimon < 0 {
+		i.Bond.Miimon = DefaultBondMIIMonitorInterval
+	}
+
+	if err := h.nlHandle.LinkAdd(i.Bond); err != nil {
+		return fmt.Errorf("failed to add bond: %w", err)
+	}
+
+	bond, err := h.nlHandle.LinkByName(i.Name)
+	if err != nil {
+		return fmt.Errorf("failed to find interface %s: %w", i.Name, err)
+	}
+
+	for _, m := range i.BondMembers {
+		peerDev := fmt.Sprintf("%s-%s", h.Name(), m.Name)
+
+		if err := h.linkInterface(m, peerDev); err != nil {
+			return fmt.Errorf("failed to link bond member %s: %w", m.Name, err)
+		}
+
+		// The kernel refuses to enslave interfaces which are up
+		if err := h.nlHandle.LinkSetDown(m.Link); err != nil {
+			return fmt.Errorf("failed to bring bond member %s down: %w", m.Name, err)
+		}
+
+		if err := h.nlHandle.LinkSetMaster(m.Link, bond); err != nil {
+			return fmt.Errorf("failed to attach bond member %s: %w", m.Name, err)
+		}
+
+		if err := h.nlHandle.LinkSetUp(m.Link); err != nil {
+			return fmt.Errorf("failed to bring bond member %s up: %w", m.Name, err)
+		}
+	}
+
+	i.Link = bond
+
+	return h.ConfigureInterface(i)
+}
+
+// BondMemberState returns the current state of a member of a bond interface.
+func (i *Interface) BondMemberState() (*nl.BondSlave, error) {
+	l, err := i.Node.NetlinkHandle().LinkByIndex(i.Link.Attrs().Index)
+	if err != nil {
+		return nil, err
+	}
+
+	s, ok := l.Attrs().Slave.(*nl.BondSlave)
+	if !ok {
+		return nil, errNoBondMember
+	}
+
+	return s, nil
+}
+
+// ActiveBondMember returns the member interface of a bond which is currently active.
+// An error is returned if there is no active member, or the bond mode does not
+// support a single active member (e.g. balance-rr).
+func (i *Interface) ActiveBondMember() (*Interface, error) {
+	l, err := i.Node.NetlinkHandle().LinkByIndex(i.Link.Attrs().Index)
+	if err != nil {
+		return nil, err
+	}
+
+	b, ok := l.(*nl.Bond)
+	if !ok {
+		return nil, errNoBond
+	}
+
+	for _, m := range i.BondMembers {
+		if m.Link.Attrs().Index == b.ActiveSlave {
+			return m, nil
+		}
+	}
+
+	return nil, errNoActive
+}
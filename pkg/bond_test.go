@@ -0,0 +1,97 @@
+// SPDX-FileCopyrightText: 2023 Steffen Vogel <post@steffenvogel.de>
+// SPDX-License-Identifier: Apache-2.0
+
+package gont_test
+
+import (
+	"testing"
+	"time"
+
+	g "cunicu.li/gont/v2/pkg"
+	o "cunicu.li/gont/v2/pkg/options"
+	"github.com/stretchr/testify/require"
+	nl "github.com/vishvananda/netlink"
+)
+
+// TestBondActiveBackup checks the failover of an active-backup bond
+//
+//	h1 <=> sw <-> h2
+func TestBondActiveBackup(t *testing.T) {
+	n, err := g.NewNetwork(*nname)
+	require.NoError(t, err, "Failed to create network")
+	defer n.MustClose()
+
+	sw, err := n.AddSwitch("sw")
+	require.NoError(t, err, "Failed to create switch")
+
+	h1, err := n.AddHost("h1",
+		g.NewInterface("bond0",
+			o.Bond(o.BondModeActiveBackup,
+				g.NewInterface("veth0", sw),
+				g.NewInterface("veth1", sw)),
+			o.AddressIP("10.0.0.1/24")))
+	require.NoError(t, err, "Failed to create host")
+
+	h2, err := n.AddHost("h2",
+		g.NewInterface("veth0", sw,
+			o.AddressIP("10.0.0.2/24")))
+	require.NoError(t, err, "Failed to create host")
+
+	err = g.TestConnectivity(h1, h2)
+	require.NoError(t, err, "Failed to test connectivity between hosts")
+
+	bond := h1.Interface("bond0")
+	require.NotNil(t, bond, "Failed to find bond interface")
+
+	active, err := bond.ActiveBondMember()
+	require.NoError(t, err, "Failed to get active bond member")
+
+	state, err := active.BondMemberState()
+	require.NoError(t, err, "Failed to get bond member state")
+	require.Equal(t, nl.BondStateActive, state.State)
+
+	err = active.SetDown()
+	require.NoError(t, err, "Failed to bring down bond member")
+
+	require.Eventually(t, func() bool {
+		newActive, err := bond.ActiveBondMember()
+		return err == nil && newActive != active
+	}, 5*time.Second, 100*time.Millisecond, "Bond did not fail over")
+
+	err = g.TestConnectivity(h1, h2)
+	require.NoError(t, err, "Failed to test connectivity between hosts after failover")
+}
+
+// TestBondBalanceRR checks connectivity via a round-robin bond
+//
+//	h1 <=> sw <-> h2
+func TestBondBalanceRR(t *testing.T) {
+	n, err := g.NewNetwork(*nname)
+	require.NoError(t, err, "Failed to create network")
+	defer n.MustClose()
+
+	sw, err := n.AddSwitch("sw")
+	require.NoError(t, err, "Failed to create switch")
+
+	h1, err := n.AddHost("h1",
+		g.NewInterface("bond0",
+			o.BondWithOptions([]g.BondOption{o.BondModeBalanceRR, o.BondMIIMonitor(50 * time.Millisecond)},
+				g.NewInterface("veth0", sw),
+				g.NewInterface("veth1", sw)),
+			o.AddressIP("10.0.0.1/24")))
+	require.NoError(t, err, "Failed to create host")
+
+	h2, err := n.AddHost("h2",
+		g.NewInterface("veth0", sw,
+			o.AddressIP("10.0.0.2/24")))
+	require.NoError(t, err, "Failed to create host")
+
+	err = g.TestConnectivity(h1, h2)
+	require.NoError(t, err, "Failed to test connectivity between hosts")
+
+	for _, name := range []string{"veth0", "veth1"} {
+		state, err := h1.Interface(name).BondMemberState()
+		require.NoError(t, err, "Failed to get bond member state")
+		require.Equal(t, nl.BondLinkUp, state.MiiStatus)
+	}
+}
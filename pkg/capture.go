@@ -6,7 +6,7 @@ package gont
 import (
 	"bytes"
 	"fmt"
-	"io"
+	"net"
 	"os"
 	"sync"
 	"sync/atomic"
@@ -39,6 +39,8 @@ type filenameTemplate struct {
 	Node      string
 	Network   string
 	PID       int
+	Index     int       // Index of the segment of a rotated file
+	Time      time.Time // Start time of the segment of a rotated file
 }
 
 func (t filenameTemplate) execute(filename string) (string, error) {
@@ -83,14 +85,46 @@ type Capture struct {
 	Pipenames   []string
 	ListenAddrs []string
 
-	writer     *pcapgo.NgWriter
-	stop       chan any
-	queue      *prque.PriorityQueue[CapturePacket, int64]
-	count      atomic.Uint64
-	interfaces []*captureInterface
-	closables  []io.Closer
-	logger     *zap.Logger
-	mu         sync.Mutex
+	WaitForViewer bool // Wait for a viewer to connect to each of the ListenAddrs before capturing
+
+	// Split options
+	SplitByInterface bool // Write a separate file per interface
+	SplitByNode      bool // Write a separate file per node
+	DuplicateTrace   bool // Include trace events in each of the split files
+
+	// Rotation options
+	RotateSize     int64         // Start a new file after the current one exceeds this size in bytes
+	RotateDuration time.Duration // Start a new file after the current one has been opened for this duration
+	RotateFiles    int           // Number of files which are kept as a ring buffer
+
+	// Queue options
+	ReorderWindow    time.Duration     // Packets are held back for this duration to order them by their timestamp
+	MaxQueuedPackets int               // Maximum number of packets held in the reordering queue
+	MaxQueuedBytes   int               // Maximum number of bytes held in the reordering queue
+	DropPolicy       CaptureDropPolicy // Decides which packets are dropped once the queue is full
+
+	TrackFlows bool // Aggregate captured packets into flows which are returned by Flows()
+
+	writers       []*captureWriter
+	streamWriters []*captureWriter
+	listeners     []*captureListener
+	fileWriters   map[string][]*captureWriter
+	duplicates    []*captureInterface
+	watchers      map[*captureWatcher]any
+	flows         *flowTable
+	watchersLock  sync.RWMutex
+	stop          chan any
+	queue         *prque.PriorityQueue[CapturePacket, int64]
+	queuedBytes   int
+	queueLock     sync.Mutex
+	writeLock     sync.Mutex // Orders dequeuing and writing of packets
+	writeErrs     chan error
+	writeFailed   atomic.Bool
+	count         atomic.Uint64
+	interfaces    []*captureInterface
+	attached      []*BaseNode // Nodes opened by AttachNode() whose handles are closed by Close()
+	logger        *zap.Logger
+	mu            sync.Mutex
 }
 
 func (c *Capture) ApplyInterface(i *Interface) {
@@ -113,16 +147,23 @@ func NewCapture(opts ...CaptureOption) *Capture {
 	c := &Capture{
 		// Default options
 		SnapshotLength: 1600,
+		ReorderWindow:  1 * time.Second,
 
-		stop:   make(chan any),
-		queue:  prque.New[CapturePacket, int64](),
-		logger: zap.L().Named("capture"),
+		stop:      make(chan any),
+		queue:     prque.New[CapturePacket, int64](),
+		writeErrs: make(chan error, 1),
+		watchers:  map[*captureWatcher]any{},
+		logger:    zap.L().Named("capture"),
 	}
 
 	for _, opt := range opts {
 		opt.ApplyCapture(c)
 	}
 
+	if c.TrackFlows {
+		c.flows = newFlowTable()
+	}
+
 	return c
 }
 
@@ -132,10 +173,11 @@ func (c *Capture) Count() uint64 {
 }
 
 func (c *Capture) Flush() error {
-	for c.queue.Len() > 0 {
-		p, _ := c.queue.Get()
-		if err := c.writePacket(p); err != nil {
+	for {
+		if ok, err := c.writeOldest(time.Time{}); err != nil {
 			return err
+		} else if !ok {
+			break
 		}
 	}
 
@@ -145,7 +187,16 @@ func (c *Capture) Flush() error {
 		}
 	}
 
-	return c.writer.Flush()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, w := range c.writers {
+		if err := w.Flush(); err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
 
 func (c *Capture) Close() error {
@@ -155,18 +206,28 @@ func (c *Capture) Close() error {
 		return fmt.Errorf("failed to flush: %w", err)
 	}
 
-	for _, closable := range c.closables {
-		if err := closable.Close(); err != nil {
+	for _, w := range c.writers {
+		if err := w.Close(); err != nil {
 			return fmt.Errorf("failed to close: %w", err)
 		}
 	}
 
+	for _, n := range c.attached {
+		n.nlHandle.Close()
+
+		if err := n.NsHandle.Close(); err != nil {
+			return fmt.Errorf("failed to close network namespace of node %s: %w", n, err)
+		}
+	}
+
 	return nil
 }
 
 func (c *Capture) newPacket(cp CapturePacket) {
 	if c.FilterPackets == nil || c.FilterPackets(&cp) {
-		c.queue.Put(cp, cp.Timestamp.UnixMicro())
+		if c.enqueue(cp) {
+			c.notifyWatchers(cp)
+		}
 	}
 }
 
@@ -174,30 +235,58 @@ func (c *Capture) writeDecryptionSecret(typ uint32, payload []byte) error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	return c.writer.WriteDecryptionSecretsBlock(typ, payload)
+	for _, w := range c.writers {
+		if err := w.writeDecryptionSecret(typ, payload); err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
 
 func (c *Capture) writePacket(p CapturePacket) error {
 	ci := p.CaptureInfo
-	ci.InterfaceIndex = p.Interface.pcapInterfaceIndex
 
 	c.mu.Lock()
 
-	if err := c.writer.WritePacket(ci, p.Data); err != nil {
-		c.mu.Unlock()
-		return fmt.Errorf("failed to write packet: %w", err)
+	opts := pcapgo.NgPacketOptions{}
+	for _, a := range ci.AncillaryData {
+		if comment, ok := a.(packetComment); ok {
+			opts.Comments = append(opts.Comments, string(comment))
+		}
+	}
+
+	for _, w := range p.Interface.writers {
+		if err := c.rotateIfNeeded(w, time.Now()); err != nil {
+			c.mu.Unlock()
+			return fmt.Errorf("failed to rotate file: %w", err)
+		}
+
+		if err := w.writePacket(p.Interface, ci, p.Data, opts); err != nil {
+			c.mu.Unlock()
+			return fmt.Errorf("failed to write packet: %w", err)
+		}
 	}
 
 	count := c.count.Add(1)
 	if c.FlushEach > 0 && count%c.FlushEach == 0 {
-		if err := c.writer.Flush(); err != nil {
-			c.mu.Unlock()
-			return fmt.Errorf("failed to flush: %w", err)
+		for _, w := range p.Interface.writers {
+			if err := w.Flush(); err != nil {
+				c.mu.Unlock()
+				return fmt.Errorf("failed to flush: %w", err)
+			}
 		}
 	}
 
 	c.mu.Unlock() // We unlock before writing to channels and invoking callbacks
 
+	if c.flows != nil {
+		c.flows.add(p.InterfaceName(), p.CaptureInfo, p.Decode(gopacket.DecodeOptions{
+			Lazy:   true,
+			NoCopy: true,
+		}))
+	}
+
 	// Notify other consumer about new packet
 	for _, ch := range c.Channels {
 		ch <- p
@@ -214,21 +303,86 @@ func (c *Capture) writeStats(ci *captureInterface) error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
+	stats := c.interfaceStats(ci)
+
+	for _, w := range ci.writers {
+		if err := w.writeStats(ci, stats); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *Capture) interfaceStats(ci *captureInterface) pcapgo.NgInterfaceStatistics {
 	counters, err := ci.source.Stats()
 	if err != nil {
 		ci.logger.Error("Failed to get interface statistics", zap.Error(err))
 	}
 
-	return c.writer.WriteInterfaceStats(ci.pcapInterfaceIndex, pcapgo.NgInterfaceStatistics{
+	// Packets dropped by the reordering queue are accounted as dropped by the interface
+	return pcapgo.NgInterfaceStatistics{
 		StartTime:       ci.StartTime,
 		LastUpdate:      time.Now(),
 		PacketsReceived: counters.PacketsReceived,
-		PacketsDropped:  counters.PacketsDropped,
-	})
+		PacketsDropped:  counters.PacketsDropped + ci.dropped.Load(),
+	}
+}
+
+// rotateIfNeeded finalizes the current file of a writer and starts a new one
+// if the file exceeds the configured size or duration.
+// The caller must hold c.mu.
+func (c *Capture) rotateIfNeeded(w *captureWriter, now time.Time) error {
+	if w.file == nil {
+		return nil
+	}
+
+	if (c.RotateSize <= 0 || w.size() < c.RotateSize) &&
+		(c.RotateDuration <= 0 || now.Sub(w.file.opened) < c.RotateDuration) {
+		return nil
+	}
+
+	// Finalize the current segment with interface statistics
+	for _, ci := range w.interfaces {
+		if err := w.writeStats(ci, c.interfaceStats(ci)); err != nil {
+			return fmt.Errorf("failed to write stats: %w", err)
+		}
+	}
+
+	if err := w.finish(); err != nil {
+		return err
+	}
+
+	// A new section is started in the current segment if the next one can not be opened.
+	// PCAP files only support a single header. Hence, they can not be continued.
+	if err := w.file.next(now); err != nil {
+		if w.format == CaptureFormatPCAP {
+			return fmt.Errorf("failed to rotate file: %w", err)
+		}
+
+		c.logger.Error("Failed to rotate file. Continuing with current segment", zap.Error(err))
+		w.file.restart(now)
+	}
+
+	return w.reset()
+}
+
+func (c *Capture) rotateFiles(now time.Time) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, w := range c.writers {
+		if err := c.rotateIfNeeded(w, now); err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
 
 func (c *Capture) writePackets() {
-	tickerPackets := time.NewTicker(1 * time.Second)
+	tickerPackets := time.NewTicker(c.writeInterval())
+	tickerRotate := time.NewTicker(1 * time.Second)
 	tickerStats := time.NewTicker(10 * time.Second)
 
 out:
@@ -241,73 +395,206 @@ out:
 				}
 			}
 
-		case now := <-tickerPackets.C:
-			for c.queue.Len() >= 1 {
-				_, oldestMicros := c.queue.Peek()
-				oldest := time.UnixMicro(oldestMicros)
-				oldestAge := now.Sub(oldest)
-				if oldestAge < 1*time.Second {
-					break
+		case now := <-tickerRotate.C:
+			if c.RotateDuration > 0 {
+				if err := c.rotateFiles(now); err != nil {
+					c.logger.Error("Failed to rotate files", zap.Error(err))
 				}
+			}
 
-				p, _ := c.queue.Get()
-
-				if err := c.writePacket(p); err != nil {
-					c.logger.Error("Failed to handle packet. Stop capturing...", zap.Error(err))
-					break out
-				}
+		case now := <-tickerPackets.C:
+			if err := c.writeQueue(now); err != nil {
+				c.failWrite(err)
 			}
 
+		case err := <-c.writeErrs:
+			c.logger.Error("Failed to handle packet. Stop capturing...", zap.Error(err))
+			break out
+
 		case <-c.stop:
 			return
 		}
 	}
 }
 
-func (c *Capture) createWriter(i *captureInterface) (*pcapgo.NgWriter, error) {
-	var err error
-	wrs := []io.Writer{}
+// addInterface adds an interface to the outputs of the capture and starts reading its packets.
+func (c *Capture) addInterface(ci *captureInterface) error {
+	first, err := c.addOutputs(ci)
+	if err != nil {
+		return err
+	}
 
-	// File handlers
-	for _, file := range c.Files {
-		wrs = append(wrs, file)
+	if first {
+		if c.WaitForViewer {
+			c.waitForViewers()
+		}
+
+		go c.writePackets()
 	}
 
-	// Filenames
+	go ci.readPackets(c)
+
+	return nil
+}
+
+// addOutputs adds an interface to all outputs of the capture.
+// It returns true for the first interface whose addition created the outputs.
+func (c *Capture) addOutputs(ci *captureInterface) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	first := c.fileWriters == nil
+	if first {
+		c.fileWriters = map[string][]*captureWriter{}
+
+		if err := c.createStreamWriters(ci); err != nil {
+			return false, err
+		}
+	} else {
+		for _, w := range c.streamWriters {
+			if err := w.addInterface(ci); err != nil {
+				return false, err
+			}
+		}
+	}
+
+	if c.split() && c.DuplicateTrace && ci.pcapInterface.LinkType == LinkTypeTrace {
+		// The trace interface is added to all existing and future files
+		for _, ws := range c.fileWriters {
+			for _, w := range ws {
+				if err := w.addInterface(ci); err != nil {
+					return false, err
+				}
+			}
+		}
+
+		c.duplicates = append(c.duplicates, ci)
+	} else if err := c.addFileInterface(ci); err != nil {
+		return false, err
+	}
+
+	ci.StartTime = time.Now()
+
+	c.interfaces = append(c.interfaces, ci)
+
+	return first, nil
+}
+
+// addFileInterface adds an interface to the files with the matching split key.
+// New files are created for keys which have not been seen before.
+func (c *Capture) addFileInterface(ci *captureInterface) error {
+	key := c.splitKey(ci)
+
+	if ws, ok := c.fileWriters[key]; ok {
+		for _, w := range ws {
+			if err := w.addInterface(ci); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	ws := []*captureWriter{}
 	for _, filename := range c.Filenames {
-		file, err := c.openFile(filename, i)
+		// Writers of different keys would truncate and write into the same file
+		if key != "" {
+			if err := checkSplitTemplate(filename, ci.template, c.SplitByInterface); err != nil {
+				return err
+			}
+		}
+
+		file, err := newCaptureFile(filename, ci.template, c.RotateSize > 0 || c.RotateDuration > 0, c.RotateFiles)
+		if err != nil {
+			return err
+		}
+
+		format, compression := CaptureFormatFromFilename(filename)
+
+		w, err := newCaptureWriter(file, file, format, compression, ci, c.writerOptions(ci), c.logger)
+		if err != nil {
+			return err
+		}
+
+		w.file = file
+
+		for _, dci := range c.duplicates {
+			if err := w.addInterface(dci); err != nil {
+				return err
+			}
+		}
+
+		ws = append(ws, w)
+	}
+
+	c.fileWriters[key] = ws
+	c.writers = append(c.writers, ws...)
+
+	return nil
+}
+
+// createStreamWriters creates the writers for all outputs which are not split.
+func (c *Capture) createStreamWriters(ci *captureInterface) error {
+	opts := c.writerOptions(ci)
+
+	// File handlers
+	for _, file := range c.Files {
+		format, compression := CaptureFormatFromFilename(file.Name())
+
+		w, err := newCaptureWriter(file, nil, format, compression, ci, opts, c.logger)
 		if err != nil {
-			return nil, fmt.Errorf("failed to open file: %w", err)
+			return err
 		}
 
-		wrs = append(wrs, file)
-		c.closables = append(c.closables, file)
+		c.streamWriters = append(c.streamWriters, w)
 	}
 
 	// Pipenames
 	for _, pipename := range c.Pipenames {
 		pipe, err := c.createAndOpenPipe(pipename)
 		if err != nil {
-			return nil, fmt.Errorf("failed to create pipe: %w", err)
+			return fmt.Errorf("failed to create pipe: %w", err)
 		}
 
-		wrs = append(wrs, pipe)
-		c.closables = append(c.closables, pipe)
+		format, compression := CaptureFormatFromFilename(pipename)
+
+		w, err := newCaptureWriter(pipe, pipe, format, compression, ci, opts, c.logger)
+		if err != nil {
+			return err
+		}
+
+		c.streamWriters = append(c.streamWriters, w)
 	}
 
 	// Listeners
 	for _, lAddr := range c.ListenAddrs {
-		listener, err := c.createListener(lAddr)
+		listener, err := newCaptureListener(lAddr)
 		if err != nil {
-			return nil, fmt.Errorf("failed to create listener: %w", err)
+			return fmt.Errorf("failed to create listener: %w", err)
 		}
 
-		wrs = append(wrs, listener)
-		c.closables = append(c.closables, listener)
+		// Live viewers always receive uncompressed PCAPng
+		w, err := newCaptureWriter(listener, listener, CaptureFormatPCAPng, CaptureCompressionNone, ci, opts, c.logger)
+		if err != nil {
+			return err
+		}
+
+		listener.serve(func(conn net.Conn) error {
+			return c.acceptViewer(w, listener, conn)
+		})
+
+		c.logger.Info("Opened listener", zap.String("addr", lAddr))
+
+		c.streamWriters = append(c.streamWriters, w)
+		c.listeners = append(c.listeners, listener)
 	}
 
-	wr := io.MultiWriter(wrs...)
+	c.writers = append(c.writers, c.streamWriters...)
+
+	return nil
+}
 
+func (c *Capture) writerOptions(i *captureInterface) pcapgo.NgWriterOptions {
 	comment := c.Comment
 	if comment == "" {
 		if i.Interface == nil {
@@ -317,23 +604,29 @@ func (c *Capture) createWriter(i *captureInterface) (*pcapgo.NgWriter, error) {
 		}
 	}
 
-	opts := pcapgo.NgWriterOptions{
+	return pcapgo.NgWriterOptions{
 		SectionInfo: pcapgo.NgSectionInfo{
 			OS:          "Linux",
 			Application: "Gont",
 			Comment:     comment,
 		},
 	}
+}
 
-	// The first interface has always id 0
-	i.pcapInterfaceIndex = 0
+func (c *Capture) split() bool {
+	return c.SplitByInterface || c.SplitByNode
+}
 
-	writer, err := pcapgo.NewNgWriterInterface(wr, i.pcapInterface, opts)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create PCAPng writer: %w", err)
+// splitKey returns the key of the files to which the packets of an interface are written.
+func (c *Capture) splitKey(ci *captureInterface) string {
+	switch {
+	case c.SplitByInterface:
+		return ci.template.Node + "/" + ci.template.Interface
+	case c.SplitByNode:
+		return ci.template.Node
+	default:
+		return ""
 	}
-
-	return writer, err
 }
 
 func (c *Capture) startInterface(i *Interface) (*captureInterface, error) {
@@ -341,12 +634,17 @@ func (c *Capture) startInterface(i *Interface) (*captureInterface, error) {
 	var hdl packetSource
 
 	if err := i.Node.RunFunc(func() error {
-		hdl, err = c.createPCAPHandle(i.Name)
+		hdl, err = c.createPCAPHandle(i)
 		return err
 	}); err != nil {
 		return nil, fmt.Errorf("failed to get PCAP handle: %w", err)
 	}
 
+	description := "Linux interface"
+	if i.Link != nil {
+		description = fmt.Sprintf("Linux %s interface", i.Link.Type())
+	}
+
 	ci := &captureInterface{
 		Interface: i,
 		source:    hdl,
@@ -356,36 +654,25 @@ func (c *Capture) startInterface(i *Interface) (*captureInterface, error) {
 			LinkType:    hdl.LinkType(),
 			SnapLength:  uint32(c.SnapshotLength), //nolint:gosec
 			OS:          "Linux",
-			Description: "Linux veth pair",
+			Description: description,
 			Comment:     fmt.Sprintf("Gont Network: '%s'", i.Node.Network().Name),
 		},
+		template: filenameTemplate{
+			Network:   i.Node.Network().Name,
+			Node:      i.Node.Name(),
+			Interface: i.Name,
+		},
 		logger: c.logger.With(zap.String("intf", i.Name)),
 	}
 
-	if c.writer == nil {
-		if c.writer, err = c.createWriter(ci); err != nil {
-			return nil, err
-		}
-
-		go c.writePackets()
-	} else {
-		if ci.pcapInterfaceIndex, err = c.writer.AddInterface(ci.pcapInterface); err != nil {
-			return nil, fmt.Errorf("failed to add interface: %w", err)
-		}
+	if err := c.addInterface(ci); err != nil {
+		return nil, err
 	}
 
-	ci.StartTime = time.Now()
-
-	c.interfaces = append(c.interfaces, ci)
-
-	go ci.readPackets(c)
-
 	return ci, nil
 }
 
 func (c *Capture) startTrace() (*captureInterface, *traceEventPacketSource, error) {
-	var err error
-
 	tps := newTracepointPacketSource()
 
 	ci := &captureInterface{
@@ -396,42 +683,52 @@ func (c *Capture) startTrace() (*captureInterface, *traceEventPacketSource, erro
 			OS:          "Debug",
 			Description: "Trace output",
 		},
+		template: filenameTemplate{
+			Interface: "tracer",
+		},
 		source: tps,
 		logger: c.logger.With(zap.String("intf", "tracer")),
 	}
 
-	if c.writer == nil {
-		if c.writer, err = c.createWriter(ci); err != nil {
-			return nil, nil, err
-		}
-
-		go c.writePackets()
-	} else {
-		if ci.pcapInterfaceIndex, err = c.writer.AddInterface(ci.pcapInterface); err != nil {
-			return nil, nil, fmt.Errorf("failed to add interface: %w", err)
-		}
+	if err := c.addInterface(ci); err != nil {
+		return nil, nil, err
 	}
 
-	ci.StartTime = time.Now()
+	return ci, tps, nil
+}
 
-	c.interfaces = append(c.interfaces, ci)
+// acceptViewer brings a newly connected viewer of a listener up to date
+// by sending the section header, interface descriptions and decryption secrets
+// before it receives any further packets.
+func (c *Capture) acceptViewer(w *captureWriter, l *captureListener, conn net.Conn) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 
-	go ci.readPackets(c)
+	// Pending data must not be sent to the new viewer
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("failed to flush: %w", err)
+	}
 
-	return ci, tps, nil
-}
+	// A viewer which does not read its header must not block the capture
+	if err := conn.SetWriteDeadline(time.Now().Add(captureListenerWriteTimeout)); err != nil {
+		return fmt.Errorf("failed to set write deadline: %w", err)
+	}
 
-func (c *Capture) createListener(lAddr string) (*captureListener, error) {
-	listener, err := newCaptureListener(lAddr)
-	if err != nil {
-		return nil, err
+	if err := w.writeHeader(conn); err != nil {
+		return fmt.Errorf("failed to write header: %w", err)
 	}
 
-	// Wait for first connection before proceeding
-	c.logger.Info("Opened listener. Waiting for a reader...", zap.String("addr", lAddr))
-	<-listener.Conns
+	l.addConn(conn)
 
-	return listener, nil
+	return nil
+}
+
+// waitForViewers blocks until a viewer has connected to each listener.
+func (c *Capture) waitForViewers() {
+	for _, l := range c.listeners {
+		c.logger.Info("Waiting for a viewer...", zap.String("addr", l.listener.Addr().String()))
+		<-l.Conns
+	}
 }
 
 func (c *Capture) createAndOpenPipe(pipename string) (*os.File, error) {
@@ -468,21 +765,3 @@ func (c *Capture) createAndOpenPipe(pipename string) (*os.File, error) {
 
 	return pipe, nil
 }
-
-func (c *Capture) openFile(filename string, i *captureInterface) (*os.File, error) {
-	var err error
-
-	if i.Interface != nil {
-		tpl := filenameTemplate{
-			Network:   i.Node.Network().Name,
-			Node:      i.Node.Name(),
-			Interface: i.Name,
-		}
-
-		if filename, err = tpl.execute(filename); err != nil {
-			return nil, err
-		}
-	}
-
-	return os.OpenFile(filename, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0o644)
-}
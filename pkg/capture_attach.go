@@ -0,0 +1,172 @@
+// SPDX-FileCopyrightText: 2023 Steffen Vogel <post@steffenvogel.de>
+// SPDX-License-Identifier: Apache-2.0
+
+package gont
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/gopacket/gopacket/layers"
+	nl "github.com/vishvananda/netlink"
+	"github.com/vishvananda/netns"
+	"go.uber.org/zap"
+)
+
+const traceSocketName = "trace.sock"
+
+var errNoSuchInterface = errors.New("non-existing interface")
+
+// AttachNode starts capturing packets on the interfaces of a node
+// in a running network which has been created by another process.
+//
+// All interfaces besides the loopback interface are captured if
+// no interface names are given. Interfaces are named and annotated
+// like the ones captured by a network created in the same process.
+// The handles of the node are released by Capture.Close().
+func (c *Capture) AttachNode(network, node string, intfs ...string) error {
+	n, err := openNode(network, node)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	c.attached = append(c.attached, n)
+	c.mu.Unlock()
+
+	links, err := n.nlHandle.LinkList()
+	if err != nil {
+		return fmt.Errorf("failed to list links: %w", err)
+	}
+
+	found := 0
+
+	for _, link := range links {
+		name := link.Attrs().Name
+
+		if len(intfs) > 0 {
+			if !slices.Contains(intfs, name) {
+				continue
+			}
+		} else if name == loopbackInterfaceName {
+			continue
+		}
+
+		found++
+
+		i := &Interface{
+			Name: name,
+			Node: n,
+			Link: link,
+		}
+
+		if c.FilterInterface != nil && !c.FilterInterface(i) {
+			continue
+		}
+
+		if _, err := c.startInterface(i); err != nil {
+			return fmt.Errorf("failed to capture interface %s: %w", name, err)
+		}
+	}
+
+	if len(intfs) > found {
+		return fmt.Errorf("%w in node '%s'", errNoSuchInterface, node)
+	}
+
+	return nil
+}
+
+// InterfaceNames returns the names of all interfaces of a node
+// in a running network besides the loopback interface.
+func InterfaceNames(network, node string) ([]string, error) {
+	n, err := openNode(network, node)
+	if err != nil {
+		return nil, err
+	}
+	defer n.nlHandle.Close()
+	defer n.NsHandle.Close()
+
+	links, err := n.nlHandle.LinkList()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list links: %w", err)
+	}
+
+	names := []string{}
+	for _, link := range links {
+		if name := link.Attrs().Name; name != loopbackInterfaceName {
+			names = append(names, name)
+		}
+	}
+
+	slices.Sort(names)
+
+	return names, nil
+}
+
+// InterfaceLinkType returns the link-layer type of the packets
+// captured on an interface of a node in a running network.
+func InterfaceLinkType(network, node, intf string) (layers.LinkType, error) {
+	n, err := openNode(network, node)
+	if err != nil {
+		return 0, err
+	}
+	defer n.nlHandle.Close()
+	defer n.NsHandle.Close()
+
+	link, err := n.nlHandle.LinkByName(intf)
+	if err != nil {
+		return 0, fmt.Errorf("%w '%s' in node '%s': %w", errNoSuchInterface, intf, node, err)
+	}
+
+	return linkLayerType(link), nil
+}
+
+// TraceExposed checks if the tracer of a running network exposes its events.
+// See the Expose option of the Tracer.
+func TraceExposed(network string) bool {
+	fi, err := os.Stat(filepath.Join(baseVarDir, network, traceSocketName))
+	return err == nil && fi.Mode()&os.ModeSocket != 0
+}
+
+// DialTrace connects to the tracer of a running network.
+// The connection streams the trace events as PCAPng.
+func DialTrace(network string) (net.Conn, error) {
+	return net.Dial("unix", filepath.Join(baseVarDir, network, traceSocketName))
+}
+
+// openNode opens the network namespace of a node in a running network.
+func openNode(network, node string) (*BaseNode, error) {
+	networkPath := filepath.Join(baseVarDir, network)
+	nodePath := filepath.Join(networkPath, "nodes", node)
+
+	nsh, err := netns.GetFromPath(filepath.Join(nodePath, "ns", "net"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open network namespace of node '%s': %w", node, err)
+	}
+
+	nlh, err := nl.NewHandleAt(nsh)
+	if err != nil {
+		nsh.Close()
+		return nil, fmt.Errorf("failed to create netlink handle: %w", err)
+	}
+
+	return &BaseNode{
+		Namespace: &Namespace{
+			Name:     fmt.Sprintf("gont-%s-%s", network, node),
+			NsHandle: nsh,
+			nlHandle: nlh,
+			logger:   zap.L().Named("namespace").With(zap.String("ns", node)),
+		},
+		network: &Network{
+			Name:    network,
+			VarPath: networkPath,
+		},
+		name:    node,
+		VarPath: nodePath,
+		logger:  zap.L().Named("node").With(zap.String("node", node)),
+	}, nil
+}
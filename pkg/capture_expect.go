@@ -0,0 +1,156 @@
+// SPDX-FileCopyrightText: 2023 Steffen Vogel <post@steffenvogel.de>
+// SPDX-License-Identifier: Apache-2.0
+
+package gont
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+var (
+	errNoMatchingPacket = errors.New("no matching packet captured")
+	errUnexpectedPacket = errors.New("unexpected packet captured")
+	errUnexpectedCount  = errors.New("unexpected number of matching packets")
+)
+
+// PacketMatcher decides whether a captured packet matches an expectation.
+//
+// See the match package for composable matchers.
+type PacketMatcher interface {
+	Match(p *CapturePacket) bool
+}
+
+func (f CaptureFilterPacketFunc) Match(p *CapturePacket) bool {
+	return f(p)
+}
+
+// captureWatcher collects packets matching an expectation.
+type captureWatcher struct {
+	matcher PacketMatcher
+	limit   int // Close done after this many matches
+
+	packets []CapturePacket
+	done    chan struct{}
+	mu      sync.Mutex
+}
+
+func (w *captureWatcher) observe(p CapturePacket) {
+	if !w.matcher.Match(&p) {
+		return
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if len(w.packets) >= w.limit {
+		return
+	}
+
+	w.packets = append(w.packets, p)
+
+	if len(w.packets) == w.limit {
+		close(w.done)
+	}
+}
+
+func (w *captureWatcher) matches() []CapturePacket {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.packets
+}
+
+// watch registers a watcher which observes all packets
+// passing the capture filters until the returned function is called.
+func (c *Capture) watch(m PacketMatcher, limit int) (*captureWatcher, func()) {
+	w := &captureWatcher{
+		matcher: m,
+		limit:   limit,
+		done:    make(chan struct{}),
+	}
+
+	c.watchersLock.Lock()
+	c.watchers[w] = nil
+	c.watchersLock.Unlock()
+
+	return w, func() {
+		c.watchersLock.Lock()
+		delete(c.watchers, w)
+		c.watchersLock.Unlock()
+	}
+}
+
+func (c *Capture) notifyWatchers(p CapturePacket) {
+	c.watchersLock.RLock()
+	defer c.watchersLock.RUnlock()
+
+	for w := range c.watchers {
+		w.observe(p)
+	}
+}
+
+// Expect waits for the first packet matching m.
+//
+// Only packets which are captured after the call are considered.
+// An error is returned if the context is done before a matching packet has been captured.
+func (c *Capture) Expect(ctx context.Context, m PacketMatcher) (*CapturePacket, error) {
+	pkts, err := c.ExpectAtLeast(ctx, m, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pkts[0], nil
+}
+
+// ExpectAtLeast waits until n packets matching m have been captured.
+func (c *Capture) ExpectAtLeast(ctx context.Context, m PacketMatcher, n int) ([]CapturePacket, error) {
+	if n <= 0 {
+		return nil, nil
+	}
+
+	w, stop := c.watch(m, n)
+	defer stop()
+
+	select {
+	case <-w.done:
+		return w.matches(), nil
+
+	case <-ctx.Done():
+		return nil, fmt.Errorf("%w: got %d of %d: %w", errNoMatchingPacket, len(w.matches()), n, ctx.Err())
+	}
+}
+
+// ExpectNone asserts that no packet matching m is captured within the duration d.
+func (c *Capture) ExpectNone(d time.Duration, m PacketMatcher) error {
+	return c.ExpectCount(d, m, 0)
+}
+
+// ExpectCount asserts that exactly n packets matching m are captured within the duration d.
+// It returns early as soon as more than n packets have been captured.
+func (c *Capture) ExpectCount(d time.Duration, m PacketMatcher, n int) error {
+	w, stop := c.watch(m, n+1)
+	defer stop()
+
+	select {
+	case <-w.done:
+		pkts := w.matches()
+		p := pkts[len(pkts)-1]
+
+		if n == 0 {
+			return fmt.Errorf("%w on %s at %s", errUnexpectedPacket, p.InterfaceName(), p.Timestamp)
+		}
+
+		return fmt.Errorf("%w: got more than %d", errUnexpectedCount, n)
+
+	case <-time.After(d):
+		if cnt := len(w.matches()); cnt != n {
+			return fmt.Errorf("%w: got %d, expected %d", errUnexpectedCount, cnt, n)
+		}
+
+		return nil
+	}
+}
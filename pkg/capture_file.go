@@ -0,0 +1,197 @@
+// SPDX-FileCopyrightText: 2023 Steffen Vogel <post@steffenvogel.de>
+// SPDX-License-Identifier: Apache-2.0
+
+package gont
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+var (
+	errRotateSameFilename = errors.New("rotated filename does not change. Use {{ .Index }} or {{ .Time }} in the filename template")
+	errSplitSameFilename  = errors.New("split filenames do not differ. Use {{ .Node }} or {{ .Interface }} in the filename template")
+)
+
+// captureFile is a file output of a capture which can be rotated into multiple segments.
+type captureFile struct {
+	file     *os.File
+	filename string // The filename template
+	template filenameTemplate
+
+	// Number of segments which are kept as a ring buffer
+	maxSegments int
+
+	// Statistics of the current segment
+	written int64
+	opened  time.Time
+
+	// Filenames of the segments which are kept, oldest first
+	segments []string
+}
+
+func newCaptureFile(filename string, tpl filenameTemplate, rotate bool, maxSegments int) (*captureFile, error) {
+	f := &captureFile{
+		filename:    filename,
+		template:    tpl,
+		maxSegments: maxSegments,
+	}
+
+	now := time.Now()
+
+	// Rotated segments must not overwrite each other
+	if rotate {
+		if err := f.checkTemplate(now); err != nil {
+			return nil, err
+		}
+	}
+
+	file, fn, err := f.open(now)
+	if err != nil {
+		return nil, err
+	}
+
+	f.start(file, fn, now)
+
+	return f, nil
+}
+
+func (f *captureFile) Write(b []byte) (int, error) {
+	n, err := f.file.Write(b)
+	f.written += int64(n)
+
+	return n, err
+}
+
+func (f *captureFile) Close() error {
+	return f.file.Close()
+}
+
+// next opens the next segment and closes the current one afterwards.
+// The current segment is kept open if the next one can not be opened.
+// The oldest segments are removed if the ring buffer is full.
+func (f *captureFile) next(now time.Time) error {
+	f.template.Index++
+
+	file, fn, err := f.open(now)
+	if err != nil {
+		f.template.Index--
+		return err
+	}
+
+	if err := f.file.Close(); err != nil {
+		file.Close() //nolint:errcheck
+		f.template.Index--
+
+		return fmt.Errorf("failed to close segment: %w", err)
+	}
+
+	f.start(file, fn, now)
+
+	// Only the current segment is tracked without a ring buffer
+	if f.maxSegments <= 0 {
+		f.segments = f.segments[len(f.segments)-1:]
+		return nil
+	}
+
+	for len(f.segments) > f.maxSegments {
+		if err := os.Remove(f.segments[0]); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to remove old segment: %w", err)
+		}
+
+		f.segments = f.segments[1:]
+	}
+
+	return nil
+}
+
+// restart keeps writing to the current segment as if it has just been opened.
+func (f *captureFile) restart(now time.Time) {
+	f.written = 0
+	f.opened = now
+}
+
+// checkTemplate ensures that the filename template yields distinct names for subsequent segments.
+func (f *captureFile) checkTemplate(now time.Time) error {
+	first, second := f.template, f.template
+
+	first.Index, first.Time = 0, now
+	second.Index, second.Time = 1, now.AddDate(0, 0, 1).Add(time.Hour+time.Minute+time.Second+time.Millisecond)
+
+	fn1, err := first.execute(f.filename)
+	if err != nil {
+		return err
+	}
+
+	fn2, err := second.execute(f.filename)
+	if err != nil {
+		return err
+	}
+
+	if fn1 == fn2 {
+		return errRotateSameFilename
+	}
+
+	return nil
+}
+
+// checkSplitTemplate ensures that the filename template yields distinct names for all split keys.
+func checkSplitTemplate(filename string, tpl filenameTemplate, byInterface bool) error {
+	variants := []filenameTemplate{tpl, tpl}
+	variants[1].Node += "-other"
+
+	if byInterface {
+		variant := tpl
+		variant.Interface += "-other"
+		variants = append(variants, variant)
+	}
+
+	first, err := tpl.execute(filename)
+	if err != nil {
+		return err
+	}
+
+	for _, variant := range variants[1:] {
+		fn, err := variant.execute(filename)
+		if err != nil {
+			return err
+		}
+
+		if fn == first {
+			return errSplitSameFilename
+		}
+	}
+
+	return nil
+}
+
+func (f *captureFile) open(now time.Time) (*os.File, string, error) {
+	tpl := f.template
+	tpl.Time = now
+
+	filename, err := tpl.execute(f.filename)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// Do not truncate the current segment
+	if n := len(f.segments); n > 0 && f.segments[n-1] == filename {
+		return nil, "", errRotateSameFilename
+	}
+
+	file, err := os.OpenFile(filename, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to open file: %w", err)
+	}
+
+	return file, filename, nil
+}
+
+func (f *captureFile) start(file *os.File, filename string, now time.Time) {
+	f.file = file
+	f.template.Time = now
+	f.segments = append(f.segments, filename)
+	f.restart(now)
+}
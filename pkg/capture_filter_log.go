@@ -0,0 +1,112 @@
+// SPDX-FileCopyrightText: 2023 Steffen Vogel <post@steffenvogel.de>
+// SPDX-License-Identifier: Apache-2.0
+
+package gont
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+
+	"github.com/gopacket/gopacket"
+	"github.com/gopacket/gopacket/layers"
+	"github.com/gopacket/gopacket/pcapgo"
+	"go.uber.org/zap"
+)
+
+var _ packetSource = (*filterLogPacketSource)(nil)
+
+type filterLogPacket struct {
+	data []byte
+	ci   gopacket.CaptureInfo
+}
+
+// filterLogPacketSource feeds packets logged by filter rules into a capture.
+type filterLogPacketSource struct {
+	packets   chan filterLogPacket
+	closed    chan struct{}
+	closeOnce sync.Once
+	stop      <-chan any // Closed once the capture is closed
+	count     atomic.Uint64
+}
+
+func newFilterLogPacketSource(stop <-chan any) *filterLogPacketSource {
+	return &filterLogPacketSource{
+		packets: make(chan filterLogPacket, 64),
+		closed:  make(chan struct{}),
+		stop:    stop,
+	}
+}
+
+func (fps *filterLogPacketSource) ReadPacketData() (data []byte, ci gopacket.CaptureInfo, err error) {
+	select {
+	case p := <-fps.packets:
+		fps.count.Add(1)
+		return p.data, p.ci, nil
+
+	case <-fps.closed:
+		return nil, gopacket.CaptureInfo{}, io.EOF
+	}
+}
+
+func (fps *filterLogPacketSource) Stats() (captureStats, error) {
+	return captureStats{
+		PacketsReceived: fps.count.Load(),
+	}, nil
+}
+
+func (fps *filterLogPacketSource) LinkType() layers.LinkType {
+	return layers.LinkTypeRaw
+}
+
+// SourcePacket passes a packet to the capture.
+// The packet is discarded if either the source or the capture has been closed.
+func (fps *filterLogPacketSource) SourcePacket(data []byte, ci gopacket.CaptureInfo) {
+	select {
+	case fps.packets <- filterLogPacket{
+		data: data,
+		ci:   ci,
+	}:
+	case <-fps.closed:
+	case <-fps.stop:
+	}
+}
+
+// Close stops the reader of the capture.
+// The packet channel itself is left open to avoid sending on a closed channel.
+func (fps *filterLogPacketSource) Close() error {
+	fps.closeOnce.Do(func() {
+		close(fps.closed)
+	})
+
+	return nil
+}
+
+func (c *Capture) startFilterLog(h *Host) (*filterLogPacketSource, error) {
+	fps := newFilterLogPacketSource(c.stop)
+
+	ci := &captureInterface{
+		pcapInterface: pcapgo.NgInterface{
+			Name:        fmt.Sprintf("%s/nflog", h.Name()),
+			LinkType:    layers.LinkTypeRaw,
+			SnapLength:  uint32(c.SnapshotLength), //nolint:gosec
+			OS:          "Linux",
+			Description: "Packets logged by filter rules",
+			Comment:     fmt.Sprintf("Gont Network: '%s'", h.network.Name),
+		},
+		template: filenameTemplate{
+			Network:   h.network.Name,
+			Node:      h.Name(),
+			Interface: "nflog",
+		},
+		source: fps,
+		logger: c.logger.With(zap.String("intf", "nflog")),
+	}
+
+	if err := c.addInterface(ci); err != nil {
+		return nil, err
+	}
+
+	return fps, nil
+}
@@ -0,0 +1,327 @@
+// SPDX-FileCopyrightText: 2023 Steffen Vogel <post@steffenvogel.de>
+// SPDX-License-Identifier: Apache-2.0
+
+package gont
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/netip"
+	"slices"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/gopacket/gopacket"
+	"github.com/gopacket/gopacket/layers"
+	"github.com/gopacket/gopacket/pcapgo"
+)
+
+// FlowCounters are the statistics of a flow.
+type FlowCounters struct {
+	Packets uint64 `json:"packets"`
+	Bytes   uint64 `json:"bytes"`
+
+	// Only for TCP flows
+	Handshakes      uint64 `json:"handshakes,omitempty"`
+	Resets          uint64 `json:"resets,omitempty"`
+	Retransmissions uint64 `json:"retransmissions,omitempty"`
+}
+
+func (fc *FlowCounters) add(o *FlowCounters) {
+	fc.Packets += o.Packets
+	fc.Bytes += o.Bytes
+	fc.Handshakes += o.Handshakes
+	fc.Resets += o.Resets
+	fc.Retransmissions += o.Retransmissions
+}
+
+// Flow is a bidirectional 5-tuple flow aggregated from captured packets.
+//
+// The source is the endpoint which sent the first packet of the flow.
+// A packet which is captured on multiple interfaces is counted once per interface.
+type Flow struct {
+	Protocol    string         `json:"protocol"`
+	Source      netip.AddrPort `json:"source"`
+	Destination netip.AddrPort `json:"destination"`
+	FirstSeen   time.Time      `json:"first_seen"`
+	LastSeen    time.Time      `json:"last_seen"`
+
+	FlowCounters
+
+	Interfaces map[string]*FlowCounters `json:"interfaces"`
+
+	tcp map[string]*flowTCPState
+}
+
+// flowTCPState tracks the TCP state of a flow on a single interface.
+type flowTCPState struct {
+	synSent    bool
+	synAckSent bool
+	nextSeq    [2]uint32 // Next expected sequence number per direction
+	seen       [2]bool
+}
+
+// Flows is a list of flows sorted by the time they were first seen.
+type Flows []*Flow
+
+// WriteJSON writes the flows as a JSON array.
+func (fs Flows) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	return enc.Encode(fs)
+}
+
+// WriteTable writes the flows as a human-readable table.
+func (fs Flows) WriteTable(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(tw, "PROTO\tSOURCE\tDESTINATION\tPACKETS\tBYTES\tDURATION\tHANDSHAKES\tRESETS\tRETRANS\tINTERFACES")
+
+	for _, f := range fs {
+		intfs := []string{}
+		for name := range f.Interfaces {
+			intfs = append(intfs, name)
+		}
+
+		slices.Sort(intfs)
+
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%d\t%s\t%d\t%d\t%d\t%s\n",
+			f.Protocol, f.Source, f.Destination,
+			f.Packets, f.Bytes, f.LastSeen.Sub(f.FirstSeen).Round(time.Millisecond),
+			f.Handshakes, f.Resets, f.Retransmissions,
+			strings.Join(intfs, ","))
+	}
+
+	return tw.Flush()
+}
+
+type flowKey struct {
+	protocol string
+	a, b     netip.AddrPort
+}
+
+// flowTable aggregates packets into flows.
+type flowTable struct {
+	flows map[flowKey]*Flow
+	mu    sync.Mutex
+}
+
+func newFlowTable() *flowTable {
+	return &flowTable{
+		flows: map[flowKey]*Flow{},
+	}
+}
+
+func (ft *flowTable) add(intf string, ci gopacket.CaptureInfo, p gopacket.Packet) {
+	var src, dst netip.Addr
+	var sport, dport uint16
+	var proto string
+
+	switch nl := p.NetworkLayer().(type) {
+	case *layers.IPv4:
+		src, dst = addrFromIP(nl.SrcIP), addrFromIP(nl.DstIP)
+		proto = strings.ToLower(nl.Protocol.String())
+	case *layers.IPv6:
+		src, dst = addrFromIP(nl.SrcIP), addrFromIP(nl.DstIP)
+		proto = strings.ToLower(nl.NextHeader.String())
+	default:
+		return
+	}
+
+	var tcp *layers.TCP
+	switch tl := p.TransportLayer().(type) {
+	case *layers.TCP:
+		sport, dport = uint16(tl.SrcPort), uint16(tl.DstPort)
+		tcp = tl
+	case *layers.UDP:
+		sport, dport = uint16(tl.SrcPort), uint16(tl.DstPort)
+	case *layers.SCTP:
+		sport, dport = uint16(tl.SrcPort), uint16(tl.DstPort)
+	}
+
+	srcAP := netip.AddrPortFrom(src, sport)
+	dstAP := netip.AddrPortFrom(dst, dport)
+
+	key := flowKey{proto, srcAP, dstAP}
+	if srcAP.Compare(dstAP) > 0 {
+		key.a, key.b = dstAP, srcAP
+	}
+
+	ft.mu.Lock()
+	defer ft.mu.Unlock()
+
+	f, ok := ft.flows[key]
+	if !ok {
+		f = &Flow{
+			Protocol:    proto,
+			Source:      srcAP,
+			Destination: dstAP,
+			FirstSeen:   ci.Timestamp,
+			Interfaces:  map[string]*FlowCounters{},
+			tcp:         map[string]*flowTCPState{},
+		}
+
+		ft.flows[key] = f
+	}
+
+	if ci.Timestamp.Before(f.FirstSeen) {
+		f.FirstSeen = ci.Timestamp
+	}
+
+	if ci.Timestamp.After(f.LastSeen) {
+		f.LastSeen = ci.Timestamp
+	}
+
+	delta := &FlowCounters{
+		Packets: 1,
+		Bytes:   uint64(ci.Length), //nolint:gosec
+	}
+
+	if tcp != nil {
+		dir := 0
+		if srcAP != f.Source {
+			dir = 1
+		}
+
+		st, ok := f.tcp[intf]
+		if !ok {
+			st = &flowTCPState{}
+			f.tcp[intf] = st
+		}
+
+		st.update(delta, tcp, dir)
+	}
+
+	ic, ok := f.Interfaces[intf]
+	if !ok {
+		ic = &FlowCounters{}
+		f.Interfaces[intf] = ic
+	}
+
+	ic.add(delta)
+	f.FlowCounters.add(delta)
+}
+
+func (ft *flowTable) list() Flows {
+	ft.mu.Lock()
+	defer ft.mu.Unlock()
+
+	fs := Flows{}
+	for _, f := range ft.flows {
+		fc := *f
+		fc.Interfaces = map[string]*FlowCounters{}
+		for name, ic := range f.Interfaces {
+			icc := *ic
+			fc.Interfaces[name] = &icc
+		}
+
+		fc.tcp = nil
+
+		fs = append(fs, &fc)
+	}
+
+	slices.SortFunc(fs, func(a, b *Flow) int {
+		return cmp.Or(
+			a.FirstSeen.Compare(b.FirstSeen),
+			a.Source.Compare(b.Source),
+			a.Destination.Compare(b.Destination),
+		)
+	})
+
+	return fs
+}
+
+// update counts handshakes, resets and retransmissions of a TCP segment sent in direction dir.
+func (st *flowTCPState) update(fc *FlowCounters, tcp *layers.TCP, dir int) {
+	switch {
+	case tcp.RST:
+		fc.Resets++
+
+	case tcp.SYN && !tcp.ACK:
+		st.synSent, st.synAckSent = true, false
+
+	case tcp.SYN && tcp.ACK:
+		st.synAckSent = st.synSent
+
+	case tcp.ACK && st.synAckSent && dir == 0:
+		fc.Handshakes++
+		st.synSent, st.synAckSent = false, false
+	}
+
+	length := uint32(len(tcp.Payload)) //nolint:gosec
+	if tcp.SYN || tcp.FIN {
+		length++
+	}
+
+	if length == 0 {
+		return
+	}
+
+	end := tcp.Seq + length
+
+	// Segments which do not advance the sequence number are retransmissions
+	if st.seen[dir] && int32(end-st.nextSeq[dir]) <= 0 { //nolint:gosec
+		fc.Retransmissions++
+		return
+	}
+
+	st.nextSeq[dir] = end
+	st.seen[dir] = true
+}
+
+// Flows returns the flows aggregated from all packets captured so far.
+// Flows are only tracked if the capture has been created with the TrackFlows option.
+func (c *Capture) Flows() Flows {
+	if c.flows == nil {
+		return nil
+	}
+
+	return c.flows.list()
+}
+
+// ReadFlows aggregates the packets of a PCAPng file into flows.
+func ReadFlows(rd io.Reader) (Flows, error) {
+	ngrd, err := pcapgo.NewNgReader(rd, pcapgo.DefaultNgReaderOptions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read PCAPng file: %w", err)
+	}
+
+	ft := newFlowTable()
+
+	for {
+		data, ci, err := ngrd.ReadPacketData()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+
+			return nil, fmt.Errorf("failed to read packet: %w", err)
+		}
+
+		intf, err := ngrd.Interface(ci.InterfaceIndex)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get interface: %w", err)
+		}
+
+		p := gopacket.NewPacket(data, intf.LinkType, gopacket.DecodeOptions{
+			Lazy:   true,
+			NoCopy: true,
+		})
+
+		ft.add(intf.Name, ci, p)
+	}
+
+	return ft.list(), nil
+}
+
+func addrFromIP(ip net.IP) netip.Addr {
+	addr, _ := netip.AddrFromSlice(ip)
+	return addr.Unmap()
+}
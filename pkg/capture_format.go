@@ -0,0 +1,199 @@
+// SPDX-FileCopyrightText: 2023 Steffen Vogel <post@steffenvogel.de>
+// SPDX-License-Identifier: Apache-2.0
+
+package gont
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/gopacket/gopacket"
+	"github.com/gopacket/gopacket/layers"
+	"github.com/gopacket/gopacket/pcapgo"
+	"github.com/klauspost/compress/zstd"
+	"go.uber.org/zap"
+)
+
+var (
+	errUnsupportedCaptureFormat      = errors.New("unsupported capture format")
+	errUnsupportedCaptureCompression = errors.New("unsupported capture compression")
+)
+
+// CaptureFormat is the file format in which captured packets are written.
+type CaptureFormat int
+
+const (
+	CaptureFormatPCAPng CaptureFormat = iota // PCAPng
+	CaptureFormatPCAP                        // Classic libpcap format
+)
+
+func (f CaptureFormat) String() string {
+	switch f {
+	case CaptureFormatPCAPng:
+		return "pcapng"
+	case CaptureFormatPCAP:
+		return "pcap"
+	default:
+		return "unknown"
+	}
+}
+
+// CaptureCompression is the compression applied to a capture output.
+type CaptureCompression int
+
+const (
+	CaptureCompressionNone CaptureCompression = iota
+	CaptureCompressionGzip
+	CaptureCompressionZstd
+)
+
+func (c CaptureCompression) String() string {
+	switch c {
+	case CaptureCompressionNone:
+		return "none"
+	case CaptureCompressionGzip:
+		return "gzip"
+	case CaptureCompressionZstd:
+		return "zstd"
+	default:
+		return "unknown"
+	}
+}
+
+// CaptureFormatFromFilename derives the format and compression of
+// a capture output from the extension of its filename.
+//
+// E.g. "capture.pcapng.zst" is a zstd-compressed PCAPng file, while
+// "capture.pcap.gz" is a gzip-compressed classic libpcap file.
+// Any other extension defaults to uncompressed PCAPng.
+func CaptureFormatFromFilename(fn string) (CaptureFormat, CaptureCompression) {
+	compression := CaptureCompressionNone
+
+	switch filepath.Ext(fn) {
+	case ".gz":
+		compression = CaptureCompressionGzip
+	case ".zst", ".zstd":
+		compression = CaptureCompressionZstd
+	}
+
+	if compression != CaptureCompressionNone {
+		fn = strings.TrimSuffix(fn, filepath.Ext(fn))
+	}
+
+	switch filepath.Ext(fn) {
+	case ".pcap", ".cap":
+		return CaptureFormatPCAP, compression
+	default:
+		return CaptureFormatPCAPng, compression
+	}
+}
+
+// captureCompressor is implemented by gzip.Writer and zstd.Encoder.
+type captureCompressor interface {
+	io.WriteCloser
+	Flush() error
+	Reset(w io.Writer)
+}
+
+var (
+	_ captureCompressor = (*gzip.Writer)(nil)
+	_ captureCompressor = (*zstd.Encoder)(nil)
+)
+
+func newCaptureCompressor(c CaptureCompression, wr io.Writer) (captureCompressor, error) {
+	switch c {
+	case CaptureCompressionGzip:
+		return gzip.NewWriter(wr), nil
+	case CaptureCompressionZstd:
+		return zstd.NewWriter(wr)
+	default:
+		return nil, fmt.Errorf("%w: %d", errUnsupportedCaptureCompression, c)
+	}
+}
+
+// captureFormatWriter is implemented by pcapgo.NgWriter and pcapWriter.
+type captureFormatWriter interface {
+	AddInterface(intf pcapgo.NgInterface) (int, error)
+	WritePacketWithOptions(ci gopacket.CaptureInfo, data []byte, opts pcapgo.NgPacketOptions) error
+	WriteInterfaceStats(intf int, stats pcapgo.NgInterfaceStatistics) error
+	WriteDecryptionSecretsBlock(secretType uint32, secretPayload []byte) error
+	Flush() error
+}
+
+var (
+	_ captureFormatWriter = (*pcapgo.NgWriter)(nil)
+	_ captureFormatWriter = (*pcapWriter)(nil)
+)
+
+func newCaptureFormatWriter(f CaptureFormat, wr io.Writer, intf pcapgo.NgInterface, opts pcapgo.NgWriterOptions, logger *zap.Logger) (captureFormatWriter, error) {
+	switch f {
+	case CaptureFormatPCAPng:
+		return pcapgo.NewNgWriterInterface(wr, intf, opts)
+	case CaptureFormatPCAP:
+		return newPCAPWriter(wr, intf, logger)
+	default:
+		return nil, fmt.Errorf("%w: %d", errUnsupportedCaptureFormat, f)
+	}
+}
+
+// pcapWriter writes packets in the classic libpcap format.
+//
+// The classic format supports only a single link-type per file.
+// Hence, packets of interfaces with a link-type differing from
+// the first interface are omitted.
+// Interface statistics and decryption secrets are not supported either.
+type pcapWriter struct {
+	writer    *pcapgo.Writer
+	linkTypes []layers.LinkType
+	logger    *zap.Logger
+}
+
+func newPCAPWriter(wr io.Writer, intf pcapgo.NgInterface, logger *zap.Logger) (*pcapWriter, error) {
+	w := &pcapWriter{
+		writer:    pcapgo.NewWriter(wr),
+		linkTypes: []layers.LinkType{intf.LinkType},
+		logger:    logger,
+	}
+
+	if err := w.writer.WriteFileHeader(intf.SnapLength, intf.LinkType); err != nil {
+		return nil, fmt.Errorf("failed to write file header: %w", err)
+	}
+
+	return w, nil
+}
+
+func (w *pcapWriter) AddInterface(intf pcapgo.NgInterface) (int, error) {
+	if intf.LinkType != w.linkTypes[0] {
+		w.logger.Warn("Omitting packets of interface with differing link-type in classic PCAP output",
+			zap.String("intf", intf.Name),
+			zap.String("link_type", intf.LinkType.String()))
+	}
+
+	w.linkTypes = append(w.linkTypes, intf.LinkType)
+
+	return len(w.linkTypes) - 1, nil
+}
+
+func (w *pcapWriter) WritePacketWithOptions(ci gopacket.CaptureInfo, data []byte, _ pcapgo.NgPacketOptions) error {
+	if w.linkTypes[ci.InterfaceIndex] != w.linkTypes[0] {
+		return nil
+	}
+
+	return w.writer.WritePacket(ci, data)
+}
+
+func (w *pcapWriter) WriteInterfaceStats(int, pcapgo.NgInterfaceStatistics) error {
+	return nil
+}
+
+func (w *pcapWriter) WriteDecryptionSecretsBlock(uint32, []byte) error {
+	return nil
+}
+
+func (w *pcapWriter) Flush() error {
+	return nil
+}
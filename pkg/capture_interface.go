@@ -6,6 +6,7 @@ package gont
 import (
 	"errors"
 	"io"
+	"sync/atomic"
 	"time"
 
 	"github.com/gopacket/gopacket/pcapgo"
@@ -20,11 +21,17 @@ type captureStats struct {
 type captureInterface struct {
 	*Interface
 
-	pcapInterfaceIndex int
-	pcapInterface      pcapgo.NgInterface
+	pcapInterface pcapgo.NgInterface
+	template      filenameTemplate
+
+	// Writers to which the packets of the interface are written
+	writers []*captureWriter
 
 	StartTime time.Time
 
+	// Packets dropped due to the queue limits of the capture
+	dropped atomic.Uint64
+
 	source packetSource
 	logger *zap.Logger
 }
@@ -8,17 +8,24 @@ import (
 	"fmt"
 	"io"
 	"net"
+	"os"
 	"strings"
 	"sync"
+	"time"
 
 	"go.uber.org/zap"
 )
 
+// captureListenerWriteTimeout limits the time spent on writing to a single viewer.
+// Viewers which can not keep up are dropped as writes block the capture.
+const captureListenerWriteTimeout = time.Second
+
 var _ io.Writer = (*captureListener)(nil)
 
 type captureListener struct {
 	listener net.Listener
 
+	// Receives each connection after it has been accepted
 	Conns chan net.Conn
 
 	connsLock sync.RWMutex
@@ -43,64 +50,114 @@ func newCaptureListener(addr string) (*captureListener, error) {
 
 	cs := &captureListener{
 		listener: lst,
-		Conns:    make(chan net.Conn),
+		Conns:    make(chan net.Conn, 1),
 		conns:    map[net.Conn]any{},
 		logger: zap.L().Named("capture.socket").With(
 			zap.String("addr", addr),
 		),
 	}
 
-	go cs.listen()
-
 	return cs, nil
 }
 
+// serve starts accepting connections.
+// The accept function is invoked for each new connection before
+// it receives any data written to the listener.
+func (cs *captureListener) serve(accept func(c net.Conn) error) {
+	go cs.listen(accept)
+}
+
 func (cs *captureListener) Close() error {
+	cs.connsLock.Lock()
+	for c := range cs.conns {
+		if err := c.Close(); err != nil && !errors.Is(err, net.ErrClosed) {
+			cs.logger.Error("Failed to close connection", zap.Error(err))
+		}
+
+		delete(cs.conns, c)
+	}
+	cs.connsLock.Unlock()
+
 	return cs.listener.Close()
 }
 
-func (cs *captureListener) Write(b []byte) (n int, err error) {
+// Write sends b to all connected viewers.
+// It never fails as viewers can come and go at any time.
+// Viewers which do not accept the data within captureListenerWriteTimeout are dropped.
+func (cs *captureListener) Write(b []byte) (int, error) {
+	failed := []net.Conn{}
+
 	cs.connsLock.RLock()
-	defer cs.connsLock.RUnlock()
 
 	for c := range cs.conns {
-		if n, err = c.Write(b); err != nil {
+		if err := writeWithDeadline(c, b); err != nil {
 			logger := cs.logger.With(zap.String("remote", c.RemoteAddr().String()))
-			if errors.Is(err, net.ErrClosed) {
+
+			switch {
+			case errors.Is(err, net.ErrClosed):
 				logger.Debug("Connection closed")
-			} else {
+			case errors.Is(err, os.ErrDeadlineExceeded):
+				logger.Warn("Viewer can not keep up. Closing...")
+			default:
 				logger.Error("Failed to write to connection. Closing...", zap.Error(err))
+			}
 
-				if err := c.Close(); err != nil {
-					logger.Error("Failed to close connection")
-				}
+			if err := c.Close(); err != nil && !errors.Is(err, net.ErrClosed) {
+				logger.Error("Failed to close connection")
 			}
 
-			cs.connsLock.RUnlock()
-			cs.removeConn(c)
-			cs.connsLock.RLock()
+			failed = append(failed, c)
 		}
 	}
 
-	return n, nil
+	cs.connsLock.RUnlock()
+
+	for _, c := range failed {
+		cs.removeConn(c)
+	}
+
+	return len(b), nil
+}
+
+// writeWithDeadline writes b to c within captureListenerWriteTimeout.
+func writeWithDeadline(c net.Conn, b []byte) error {
+	if err := c.SetWriteDeadline(time.Now().Add(captureListenerWriteTimeout)); err != nil {
+		return err
+	}
+
+	_, err := c.Write(b)
+
+	return err
 }
 
-func (cs *captureListener) listen() {
+func (cs *captureListener) listen(accept func(c net.Conn) error) {
 	for {
 		c, err := cs.listener.Accept()
 		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+
 			continue
 		}
 
-		cs.logger.Debug("New connection",
-			zap.String("remote", c.RemoteAddr().String()))
+		logger := cs.logger.With(zap.String("remote", c.RemoteAddr().String()))
+		logger.Debug("New connection")
+
+		if err := accept(c); err != nil {
+			logger.Error("Failed to accept connection. Closing...", zap.Error(err))
+
+			if err := c.Close(); err != nil {
+				logger.Error("Failed to close connection")
+			}
+
+			continue
+		}
 
 		select {
 		case cs.Conns <- c:
 		default:
 		}
-
-		cs.addConn(c)
 	}
 }
 
@@ -17,3 +17,9 @@ type CapturePacket struct {
 func (p CapturePacket) Decode(dOpts gopacket.DecodeOptions) gopacket.Packet {
 	return gopacket.NewPacket(p.Data, p.Interface.pcapInterface.LinkType, dOpts)
 }
+
+// InterfaceName returns the name of the interface on which the packet has been captured
+// in the form "node/interface".
+func (p CapturePacket) InterfaceName() string {
+	return p.Interface.pcapInterface.Name
+}
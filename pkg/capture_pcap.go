@@ -17,12 +17,12 @@ type pcapPacketSource struct {
 	*pcap.Handle
 }
 
-func (c *Capture) createPCAPHandle(name string) (packetSource, error) {
+func (c *Capture) createPCAPHandle(i *Interface) (packetSource, error) {
 	if c.Timeout.Microseconds() == 0 {
 		c.Timeout = pcap.BlockForever
 	}
 
-	ihdl, err := pcap.NewInactiveHandle(name)
+	ihdl, err := pcap.NewInactiveHandle(i.Name)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create PCAP handle: %w", err)
 	}
@@ -20,10 +20,12 @@ const CGoPCAP = false
 
 type pcapgoPacketSource struct {
 	*pcapgo.EthernetHandle
+
+	linkType layers.LinkType
 }
 
-func (c *Capture) createPCAPHandle(name string) (packetSource, error) {
-	hdl, err := pcapgo.NewEthernetHandle(name)
+func (c *Capture) createPCAPHandle(i *Interface) (packetSource, error) {
+	hdl, err := pcapgo.NewEthernetHandle(i.Name)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open PCAP handle: %w", err)
 	}
@@ -56,8 +58,14 @@ func (c *Capture) createPCAPHandle(name string) (packetSource, error) {
 		}
 	}
 
+	linkType := layers.LinkTypeEthernet
+	if i.Link != nil {
+		linkType = linkLayerType(i.Link)
+	}
+
 	return pcapgoPacketSource{
 		EthernetHandle: hdl,
+		linkType:       linkType,
 	}, nil
 }
 
@@ -74,5 +82,5 @@ func (h pcapgoPacketSource) Stats() (captureStats, error) {
 }
 
 func (h pcapgoPacketSource) LinkType() layers.LinkType {
-	return layers.LinkTypeEthernet
+	return h.linkType
 }
@@ -0,0 +1,218 @@
+// SPDX-FileCopyrightText: 2023 Steffen Vogel <post@steffenvogel.de>
+// SPDX-License-Identifier: Apache-2.0
+
+package gont
+
+import (
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// CaptureDropPolicy decides which packets are dropped once the
+// reordering queue of a capture reaches its limits.
+type CaptureDropPolicy int
+
+const (
+	CaptureDropNewest  CaptureDropPolicy = iota // Drop incoming packets
+	CaptureDropOldest                           // Drop the oldest queued packets
+	CaptureWriteOldest                          // Write the oldest queued packets before the reordering window has elapsed
+)
+
+func (p CaptureDropPolicy) String() string {
+	switch p {
+	case CaptureDropNewest:
+		return "drop-newest"
+	case CaptureDropOldest:
+		return "drop-oldest"
+	case CaptureWriteOldest:
+		return "write-oldest"
+	default:
+		return "unknown"
+	}
+}
+
+// CaptureStats are the statistics of a capture.
+type CaptureStats struct {
+	PacketsCaptured uint64 // Packets written to the outputs
+	PacketsDropped  uint64 // Packets dropped due to the queue limits
+	PacketsQueued   int    // Packets currently held in the reordering queue
+	BytesQueued     int    // Bytes currently held in the reordering queue
+
+	Interfaces map[string]CaptureInterfaceStats
+}
+
+// CaptureInterfaceStats are the statistics of a single captured interface.
+type CaptureInterfaceStats struct {
+	PacketsReceived     uint64 // Packets received by the packet source
+	PacketsDropped      uint64 // Packets dropped by the packet source, e.g. the kernel
+	PacketsDroppedQueue uint64 // Packets dropped due to the queue limits of the capture
+}
+
+// Stats returns the current statistics of the capture.
+func (c *Capture) Stats() CaptureStats {
+	c.queueLock.Lock()
+	queued, queuedBytes := c.queue.Len(), c.queuedBytes
+	c.queueLock.Unlock()
+
+	s := CaptureStats{
+		PacketsCaptured: c.count.Load(),
+		PacketsQueued:   queued,
+		BytesQueued:     queuedBytes,
+		Interfaces:      map[string]CaptureInterfaceStats{},
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, ci := range c.interfaces {
+		counters, err := ci.source.Stats()
+		if err != nil {
+			ci.logger.Error("Failed to get interface statistics", zap.Error(err))
+		}
+
+		dropped := ci.dropped.Load()
+
+		s.PacketsDropped += dropped
+		s.Interfaces[ci.pcapInterface.Name] = CaptureInterfaceStats{
+			PacketsReceived:     counters.PacketsReceived,
+			PacketsDropped:      counters.PacketsDropped,
+			PacketsDroppedQueue: dropped,
+		}
+	}
+
+	return s
+}
+
+// enqueue adds a packet to the reordering queue while respecting its limits.
+// It returns false if the packet has been dropped.
+func (c *Capture) enqueue(cp CapturePacket) bool {
+	c.queueLock.Lock()
+
+	// Overflowing packets are written while holding the ordering lock.
+	// It must be acquired before the queue lock.
+	if c.DropPolicy == CaptureWriteOldest && c.queueFull(len(cp.Data)) {
+		c.queueLock.Unlock()
+
+		c.writeLock.Lock()
+		defer c.writeLock.Unlock()
+
+		c.queueLock.Lock()
+	}
+
+	overflow := []CapturePacket{}
+
+	for c.queueFull(len(cp.Data)) {
+		if c.DropPolicy == CaptureDropNewest || c.queue.Len() == 0 {
+			c.queueLock.Unlock()
+			cp.Interface.dropped.Add(1)
+
+			return false
+		}
+
+		p, _ := c.queue.Get()
+		c.queuedBytes -= len(p.Data)
+
+		// Packets can not be written anymore once writing failed
+		if c.DropPolicy == CaptureDropOldest || c.writeFailed.Load() {
+			p.Interface.dropped.Add(1)
+		} else {
+			overflow = append(overflow, p)
+		}
+	}
+
+	c.queue.Put(cp, cp.Timestamp.UnixMicro())
+	c.queuedBytes += len(cp.Data)
+
+	c.queueLock.Unlock()
+
+	for i, p := range overflow {
+		if err := c.writePacket(p); err != nil {
+			for _, p := range overflow[i:] {
+				p.Interface.dropped.Add(1)
+			}
+
+			c.failWrite(err)
+
+			break
+		}
+	}
+
+	return true
+}
+
+// failWrite passes an error to the writer goroutine which stops capturing.
+func (c *Capture) failWrite(err error) {
+	c.writeFailed.Store(true)
+
+	select {
+	case c.writeErrs <- err:
+	default:
+	}
+}
+
+// queueFull checks if adding a packet of the given length would exceed the queue limits.
+// The caller must hold c.queueLock.
+func (c *Capture) queueFull(length int) bool {
+	return (c.MaxQueuedPackets > 0 && c.queue.Len() >= c.MaxQueuedPackets) ||
+		(c.MaxQueuedBytes > 0 && c.queuedBytes+length > c.MaxQueuedBytes)
+}
+
+// dequeue removes the oldest packet from the queue if it has been captured before the deadline.
+// A zero deadline dequeues packets regardless of their age.
+func (c *Capture) dequeue(deadline time.Time) (CapturePacket, bool) {
+	c.queueLock.Lock()
+	defer c.queueLock.Unlock()
+
+	if c.queue.Len() == 0 {
+		return CapturePacket{}, false
+	}
+
+	if !deadline.IsZero() {
+		if _, oldest := c.queue.Peek(); oldest > deadline.UnixMicro() {
+			return CapturePacket{}, false
+		}
+	}
+
+	p, _ := c.queue.Get()
+	c.queuedBytes -= len(p.Data)
+
+	return p, true
+}
+
+// writeOldest writes the oldest packet if it has been captured before the deadline.
+// A zero deadline writes packets regardless of their age.
+// It returns false if no packet has been written.
+//
+// The ordering lock keeps packets ordered by their timestamp
+// across the writer goroutine, overflowing readers and Flush().
+func (c *Capture) writeOldest(deadline time.Time) (bool, error) {
+	c.writeLock.Lock()
+	defer c.writeLock.Unlock()
+
+	p, ok := c.dequeue(deadline)
+	if !ok {
+		return false, nil
+	}
+
+	return true, c.writePacket(p)
+}
+
+// writeQueue writes all packets which are older than the reordering window.
+func (c *Capture) writeQueue(now time.Time) error {
+	deadline := now.Add(-c.ReorderWindow)
+
+	for {
+		if ok, err := c.writeOldest(deadline); err != nil {
+			return err
+		} else if !ok {
+			return nil
+		}
+	}
+}
+
+// writeInterval returns the interval in which the queue is checked for packets
+// which have left the reordering window.
+func (c *Capture) writeInterval() time.Duration {
+	return min(max(c.ReorderWindow, 10*time.Millisecond), time.Second)
+}
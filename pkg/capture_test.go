@@ -4,19 +4,25 @@
 package gont_test
 
 import (
+	"bytes"
+	"context"
 	"errors"
 	"io"
+	"net"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
 
 	g "cunicu.li/gont/v2/pkg"
+	"cunicu.li/gont/v2/pkg/match"
 	o "cunicu.li/gont/v2/pkg/options"
 	co "cunicu.li/gont/v2/pkg/options/capture"
 	"github.com/gopacket/gopacket"
 	"github.com/gopacket/gopacket/layers"
 	"github.com/gopacket/gopacket/pcapgo"
+	"github.com/klauspost/compress/zstd"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/zap"
 )
@@ -214,3 +220,613 @@ func nextPacket(t *testing.T, rd *pcapgo.NgReader) (gopacket.Packet, *pcapgo.NgI
 
 	return gopacket.NewPacket(data, layers.LinkTypeEthernet, gopacket.Default), &intf, false
 }
+
+func TestCaptureSplit(t *testing.T) {
+	dir := t.TempDir()
+
+	c := g.NewCapture(
+		co.Filename(filepath.Join(dir, "{{ .Node }}_{{ .Interface }}.pcapng")),
+		co.SplitByInterface(true),
+		co.FilterInterfaces(func(i *g.Interface) bool {
+			return strings.HasPrefix(i.Name, "veth")
+		}),
+	)
+
+	n, err := g.NewNetwork(*nname, c)
+	require.NoError(t, err, "Failed to create network")
+	defer n.MustClose()
+
+	sw1, err := n.AddSwitch("sw1")
+	require.NoError(t, err, "Failed to add switch")
+
+	h1, err := n.AddHost("h1",
+		g.NewInterface("veth0", sw1,
+			o.AddressIP("fc::1/64")))
+	require.NoError(t, err, "Failed to add host")
+
+	h2, err := n.AddHost("h2",
+		g.NewInterface("veth0", sw1,
+			o.AddressIP("fc::2/64")))
+	require.NoError(t, err, "Failed to add host")
+
+	_, err = h1.Ping(h2)
+	require.NoError(t, err, "Failed to ping")
+
+	time.Sleep(1 * time.Second)
+
+	err = c.Flush()
+	require.NoError(t, err, "Failed to flush capture")
+
+	for _, name := range []string{"h1_veth0", "h2_veth0", "sw1_veth-h1", "sw1_veth-h2"} {
+		f, err := os.Open(filepath.Join(dir, name+".pcapng"))
+		require.NoError(t, err, "Failed to open capture file")
+
+		rd, err := pcapgo.NewNgReader(f, pcapgo.DefaultNgReaderOptions)
+		require.NoError(t, err, "Failed to read PCAPng file")
+
+		_, intf, eof := nextPacket(t, rd)
+		require.False(t, eof, "Expected packets in %s", name)
+		require.Equal(t, strings.Replace(name, "_", "/", 1), intf.Name)
+		require.Equal(t, 1, rd.NInterfaces(), "Invalid number of interfaces")
+
+		err = f.Close()
+		require.NoError(t, err, "Failed to close file")
+	}
+}
+
+func TestCaptureRotate(t *testing.T) {
+	dir := t.TempDir()
+
+	c := g.NewCapture(
+		co.Filename(filepath.Join(dir, "capture-{{ .Index }}.pcapng")),
+		co.RotateSize(1),
+		co.RotateFiles(2),
+	)
+
+	n, err := g.NewNetwork(*nname, c)
+	require.NoError(t, err, "Failed to create network")
+	defer n.MustClose()
+
+	h1, err := n.AddHost("h1")
+	require.NoError(t, err, "Failed to add host")
+
+	h2, err := n.AddHost("h2")
+	require.NoError(t, err, "Failed to add host")
+
+	err = n.AddLink(
+		g.NewInterface("veth0", h1, o.AddressIP("fc::1/64")),
+		g.NewInterface("veth0", h2, o.AddressIP("fc::2/64")))
+	require.NoError(t, err, "Failed to add link")
+
+	// Each flush completes a segment which gets rotated with the next packet
+	for i := 0; i < 3; i++ {
+		_, err = h1.Ping(h2)
+		require.NoError(t, err, "Failed to ping")
+
+		time.Sleep(1 * time.Second)
+
+		err = c.Flush()
+		require.NoError(t, err, "Failed to flush capture")
+	}
+
+	_, err = os.Stat(filepath.Join(dir, "capture-0.pcapng"))
+	require.ErrorIs(t, err, os.ErrNotExist, "Oldest segment has not been removed")
+
+	segments, err := filepath.Glob(filepath.Join(dir, "capture-*.pcapng"))
+	require.NoError(t, err)
+	require.Len(t, segments, 2, "Invalid number of segments")
+
+	for _, segment := range segments {
+		f, err := os.Open(segment)
+		require.NoError(t, err, "Failed to open segment")
+
+		// Each segment starts with its own section header and interface descriptions
+		rd, err := pcapgo.NewNgReader(f, pcapgo.DefaultNgReaderOptions)
+		require.NoError(t, err, "Failed to read PCAPng file")
+
+		_, _, eof := nextPacket(t, rd)
+		require.False(t, eof, "Expected packets in segment %s", segment)
+
+		err = f.Close()
+		require.NoError(t, err, "Failed to close file")
+	}
+}
+
+func TestCaptureRotateInvalidTemplate(t *testing.T) {
+	c := g.NewCapture(
+		co.Filename(filepath.Join(t.TempDir(), "capture.pcapng")),
+		co.RotateSize(1),
+	)
+
+	n, err := g.NewNetwork(*nname, c)
+	require.NoError(t, err, "Failed to create network")
+	defer n.MustClose()
+
+	h1, err := n.AddHost("h1")
+	require.NoError(t, err, "Failed to add host")
+
+	h2, err := n.AddHost("h2")
+	require.NoError(t, err, "Failed to add host")
+
+	// Segments would overwrite each other without {{ .Index }} or {{ .Time }}
+	err = n.AddLink(
+		g.NewInterface("veth0", h1),
+		g.NewInterface("veth0", h2))
+	require.Error(t, err, "Capture started with a non-rotatable filename")
+}
+
+func TestCaptureSplitInvalidTemplate(t *testing.T) {
+	c := g.NewCapture(
+		co.Filename(filepath.Join(t.TempDir(), "{{ .Node }}.pcapng")),
+		co.SplitByInterface(true),
+	)
+
+	n, err := g.NewNetwork(*nname, c)
+	require.NoError(t, err, "Failed to create network")
+	defer n.MustClose()
+
+	h1, err := n.AddHost("h1")
+	require.NoError(t, err, "Failed to add host")
+
+	h2, err := n.AddHost("h2")
+	require.NoError(t, err, "Failed to add host")
+
+	// Interfaces of the same node would be written into the same file without {{ .Interface }}
+	err = n.AddLink(
+		g.NewInterface("veth0", h1),
+		g.NewInterface("veth0", h2))
+	require.Error(t, err, "Capture started with a non-splittable filename")
+}
+
+func TestCaptureQueueLimits(t *testing.T) {
+	dir := t.TempDir()
+	fn := filepath.Join(dir, "capture.pcapng")
+
+	c := g.NewCapture(
+		co.Filename(fn),
+		co.FilterInterfaces(func(i *g.Interface) bool {
+			return i.Name == "veth0"
+		}),
+		co.ReorderWindow(10*time.Second),
+		co.MaxQueuedPackets(2),
+		co.DropNewest,
+	)
+
+	n, err := g.NewNetwork(*nname)
+	require.NoError(t, err, "Failed to create network")
+	defer n.MustClose()
+
+	h1, err := n.AddHost("h1", c)
+	require.NoError(t, err, "Failed to add host")
+
+	h2, err := n.AddHost("h2")
+	require.NoError(t, err, "Failed to add host")
+
+	err = n.AddLink(
+		g.NewInterface("veth0", h1, o.AddressIP("fc::1/64")),
+		g.NewInterface("veth0", h2, o.AddressIP("fc::2/64")))
+	require.NoError(t, err, "Failed to add link")
+
+	for i := 0; i < 3; i++ {
+		_, err = h1.Ping(h2)
+		require.NoError(t, err, "Failed to ping")
+	}
+
+	stats := c.Stats()
+	require.Equal(t, 2, stats.PacketsQueued, "Queue limit exceeded")
+	require.Zero(t, stats.PacketsCaptured, "Packets have been written before the reordering window elapsed")
+	require.NotZero(t, stats.PacketsDropped, "No packets have been dropped")
+
+	intfStats, ok := stats.Interfaces["h1/veth0"]
+	require.True(t, ok, "Missing interface statistics")
+	require.Equal(t, stats.PacketsDropped, intfStats.PacketsDroppedQueue)
+
+	err = c.Close()
+	require.NoError(t, err, "Failed to close capture")
+
+	f, err := os.Open(fn)
+	require.NoError(t, err, "Failed to open capture file")
+	defer f.Close()
+
+	var dropped uint64
+
+	rd, err := pcapgo.NewNgReader(f, pcapgo.NgReaderOptions{
+		StatisticsCallback: func(_ int, s pcapgo.NgInterfaceStatistics) {
+			dropped = s.PacketsDropped
+		},
+	})
+	require.NoError(t, err, "Failed to read PCAPng file")
+
+	for {
+		if _, _, eof := nextPacket(t, rd); eof {
+			break
+		}
+	}
+
+	require.GreaterOrEqual(t, dropped, stats.PacketsDropped, "Drops are missing in the interface statistics")
+}
+
+func TestCaptureQueueWriteOldest(t *testing.T) {
+	dir := t.TempDir()
+	fn := filepath.Join(dir, "capture.pcapng")
+
+	c := g.NewCapture(
+		co.Filename(fn),
+		co.FilterInterfaces(func(i *g.Interface) bool {
+			return i.Name == "veth0"
+		}),
+		co.ReorderWindow(10*time.Second),
+		co.MaxQueuedPackets(2),
+		co.WriteOldest,
+	)
+
+	n, err := g.NewNetwork(*nname)
+	require.NoError(t, err, "Failed to create network")
+	defer n.MustClose()
+
+	h1, err := n.AddHost("h1", c)
+	require.NoError(t, err, "Failed to add host")
+
+	h2, err := n.AddHost("h2")
+	require.NoError(t, err, "Failed to add host")
+
+	err = n.AddLink(
+		g.NewInterface("veth0", h1, o.AddressIP("fc::1/64")),
+		g.NewInterface("veth0", h2, o.AddressIP("fc::2/64")))
+	require.NoError(t, err, "Failed to add link")
+
+	for i := 0; i < 3; i++ {
+		_, err = h1.Ping(h2)
+		require.NoError(t, err, "Failed to ping")
+	}
+
+	stats := c.Stats()
+	require.NotZero(t, stats.PacketsCaptured, "No packets have been written before the reordering window elapsed")
+	require.Zero(t, stats.PacketsDropped, "Packets have been dropped")
+
+	err = c.Close()
+	require.NoError(t, err, "Failed to close capture")
+
+	f, err := os.Open(fn)
+	require.NoError(t, err, "Failed to open capture file")
+	defer f.Close()
+
+	rd, err := pcapgo.NewNgReader(f, pcapgo.DefaultNgReaderOptions)
+	require.NoError(t, err, "Failed to read PCAPng file")
+
+	// Packets are ordered although the queue overflows
+	var last time.Time
+	for {
+		_, ci, err := rd.ReadPacketData()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		require.NoError(t, err, "Failed to read packet data")
+		require.False(t, ci.Timestamp.Before(last), "Packets are not ordered")
+
+		last = ci.Timestamp
+	}
+}
+
+func TestCaptureListener(t *testing.T) {
+	sock := filepath.Join(t.TempDir(), "capture.sock")
+
+	c := g.NewCapture(
+		co.ListenAddr("unix:"+sock),
+		co.ReorderWindow(0),
+		co.FilterPackets(func(p *g.CapturePacket) bool {
+			layer := p.Decode(gopacket.DecodeOptions{}).Layer(layers.LayerTypeICMPv6)
+			if layer == nil {
+				return false
+			}
+
+			typ := layer.(*layers.ICMPv6).TypeCode.Type() //nolint:forcetypeassert
+			return typ == layers.ICMPv6TypeEchoRequest
+		}),
+	)
+
+	n, err := g.NewNetwork(*nname, c)
+	require.NoError(t, err, "Failed to create network")
+	defer n.MustClose()
+
+	h1, err := n.AddHost("h1")
+	require.NoError(t, err, "Failed to add host")
+
+	h2, err := n.AddHost("h2")
+	require.NoError(t, err, "Failed to add host")
+
+	// The listener must not block the network setup
+	err = n.AddLink(
+		g.NewInterface("veth0", h1, o.AddressIP("fc::1/64")),
+		g.NewInterface("veth0", h2, o.AddressIP("fc::2/64")))
+	require.NoError(t, err, "Failed to add link")
+
+	_, err = h1.Ping(h2)
+	require.NoError(t, err, "Failed to ping")
+
+	// Late-joining viewers receive the section header and interface descriptions
+	rds := []*pcapgo.NgReader{}
+	for i := 0; i < 2; i++ {
+		conn, err := net.Dial("unix", sock)
+		require.NoError(t, err, "Failed to connect to listener")
+		defer conn.Close()
+
+		err = conn.SetReadDeadline(time.Now().Add(10 * time.Second))
+		require.NoError(t, err, "Failed to set deadline")
+
+		rd, err := pcapgo.NewNgReader(conn, pcapgo.DefaultNgReaderOptions)
+		require.NoError(t, err, "Failed to read section header")
+
+		rds = append(rds, rd)
+	}
+
+	_, err = h1.Ping(h2)
+	require.NoError(t, err, "Failed to ping")
+
+	for _, rd := range rds {
+		_, ci, err := rd.ReadPacketData()
+		require.NoError(t, err, "Failed to read packet")
+
+		intf, err := rd.Interface(ci.InterfaceIndex)
+		require.NoError(t, err, "Failed to get interface")
+		require.Contains(t, []string{"h1/veth0", "h2/veth0"}, intf.Name, "Invalid interface")
+	}
+}
+
+func TestCaptureAttachNode(t *testing.T) {
+	n, err := g.NewNetwork(*nname)
+	require.NoError(t, err, "Failed to create network")
+	defer n.MustClose()
+
+	h1, err := n.AddHost("h1")
+	require.NoError(t, err, "Failed to add host")
+
+	h2, err := n.AddHost("h2")
+	require.NoError(t, err, "Failed to add host")
+
+	err = n.AddLink(
+		g.NewInterface("veth0", h1, o.AddressIP("fc::1/64")),
+		g.NewInterface("veth0", h2, o.AddressIP("fc::2/64")))
+	require.NoError(t, err, "Failed to add link")
+
+	intfs, err := g.InterfaceNames(n.Name, "h1")
+	require.NoError(t, err, "Failed to list interfaces")
+	require.Equal(t, []string{"veth0"}, intfs)
+
+	// Attach like gontc to a network created by another process
+	c := g.NewCapture()
+
+	err = c.AttachNode(n.Name, "h1")
+	require.NoError(t, err, "Failed to attach to node")
+
+	err = c.AttachNode(n.Name, "h2", "eth99")
+	require.Error(t, err, "Attached to non-existing interface")
+
+	_, err = h1.Ping(h2)
+	require.NoError(t, err, "Failed to ping")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	p, err := c.Expect(ctx, match.ICMPv6().Interface("h1/veth0"))
+	require.NoError(t, err, "Missing packet")
+	require.Equal(t, "h1/veth0", p.InterfaceName())
+
+	err = c.Close()
+	require.NoError(t, err, "Failed to close capture")
+}
+
+func TestCaptureFormatFromFilename(t *testing.T) {
+	for fn, exp := range map[string]struct {
+		format      g.CaptureFormat
+		compression g.CaptureCompression
+	}{
+		"capture.pcapng":     {g.CaptureFormatPCAPng, g.CaptureCompressionNone},
+		"capture.pcapng.gz":  {g.CaptureFormatPCAPng, g.CaptureCompressionGzip},
+		"capture.pcapng.zst": {g.CaptureFormatPCAPng, g.CaptureCompressionZstd},
+		"capture.pcap":       {g.CaptureFormatPCAP, g.CaptureCompressionNone},
+		"capture.pcap.gz":    {g.CaptureFormatPCAP, g.CaptureCompressionGzip},
+		"capture":            {g.CaptureFormatPCAPng, g.CaptureCompressionNone},
+	} {
+		format, compression := g.CaptureFormatFromFilename(fn)
+		require.Equal(t, exp.format, format, "Invalid format of %s", fn)
+		require.Equal(t, exp.compression, compression, "Invalid compression of %s", fn)
+	}
+}
+
+func TestCaptureCompressed(t *testing.T) {
+	dir := t.TempDir()
+
+	c := g.NewCapture(
+		co.Filename(filepath.Join(dir, "capture.pcapng.zst")),
+		co.Filename(filepath.Join(dir, "capture.pcap.gz")),
+	)
+
+	n, err := g.NewNetwork(*nname, c)
+	require.NoError(t, err, "Failed to create network")
+
+	h1, err := n.AddHost("h1")
+	require.NoError(t, err, "Failed to add host")
+
+	h2, err := n.AddHost("h2")
+	require.NoError(t, err, "Failed to add host")
+
+	err = n.AddLink(
+		g.NewInterface("veth0", h1, o.AddressIP("fc::1/64")),
+		g.NewInterface("veth0", h2, o.AddressIP("fc::2/64")))
+	require.NoError(t, err, "Failed to add link")
+
+	_, err = h1.Ping(h2)
+	require.NoError(t, err, "Failed to ping")
+
+	time.Sleep(1 * time.Second)
+
+	err = n.Close()
+	require.NoError(t, err, "Failed to close network")
+
+	f, err := os.Open(filepath.Join(dir, "capture.pcapng.zst"))
+	require.NoError(t, err, "Failed to open file")
+	defer f.Close()
+
+	zrd, err := zstd.NewReader(f)
+	require.NoError(t, err, "Failed to create zstd reader")
+	defer zrd.Close()
+
+	ngrd, err := pcapgo.NewNgReader(zrd, pcapgo.DefaultNgReaderOptions)
+	require.NoError(t, err, "Failed to read PCAPng file")
+
+	_, _, eof := nextPacket(t, ngrd)
+	require.False(t, eof, "Expected packets")
+
+	f2, err := os.Open(filepath.Join(dir, "capture.pcap.gz"))
+	require.NoError(t, err, "Failed to open file")
+	defer f2.Close()
+
+	// pcapgo.NewReader detects gzip compression itself
+	rd, err := pcapgo.NewReader(f2)
+	require.NoError(t, err, "Failed to read PCAP file")
+	require.Equal(t, layers.LinkTypeEthernet, rd.LinkType())
+
+	_, _, err = rd.ReadPacketData()
+	require.NoError(t, err, "Expected packets")
+}
+
+func TestCaptureExpect(t *testing.T) {
+	c := g.NewCapture()
+
+	n, err := g.NewNetwork(*nname, c)
+	require.NoError(t, err, "Failed to create network")
+	defer n.MustClose()
+
+	h1, err := n.AddHost("h1")
+	require.NoError(t, err, "Failed to add host")
+
+	h2, err := n.AddHost("h2")
+	require.NoError(t, err, "Failed to add host")
+
+	err = n.AddLink(
+		g.NewInterface("veth0", h1, o.AddressIP("fc::1/64")),
+		g.NewInterface("veth0", h2, o.AddressIP("fc::2/64")))
+	require.NoError(t, err, "Failed to add link")
+
+	echoRequest := match.Layer(layers.LayerTypeICMPv6Echo).From(h1).To(h2)
+
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		h1.Ping(h2) //nolint:errcheck
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	pkt, err := c.Expect(ctx, echoRequest)
+	require.NoError(t, err, "Failed to capture echo request")
+	require.Contains(t, []string{"h1/veth0", "h2/veth0"}, pkt.InterfaceName())
+
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		h1.Ping(h2) //nolint:errcheck
+	}()
+
+	err = c.ExpectCount(2*time.Second, match.All(echoRequest, match.Packet().Interface("h1/veth0")), 1)
+	require.NoError(t, err, "Unexpected number of echo requests")
+
+	err = c.ExpectNone(time.Second, match.TCP().To(h2))
+	require.NoError(t, err, "Unexpected TCP packets")
+
+	ctx2, cancel2 := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel2()
+
+	_, err = c.Expect(ctx2, match.UDP().DPort(53))
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestCaptureFlows(t *testing.T) {
+	buf := &bytes.Buffer{}
+
+	wr, err := pcapgo.NewNgWriterInterface(buf, pcapgo.NgInterface{
+		Name:     "h1/veth0",
+		LinkType: layers.LinkTypeRaw,
+	}, pcapgo.DefaultNgWriterOptions)
+	require.NoError(t, err, "Failed to create writer")
+
+	h1 := net.ParseIP("10.0.0.1").To4()
+	h2 := net.ParseIP("10.0.0.2").To4()
+	start := time.Now()
+
+	segment := func(i int, src, dst net.IP, sport, dport uint16, seq uint32, flags string, payload string) {
+		ip := &layers.IPv4{
+			Version:  4,
+			TTL:      64,
+			Protocol: layers.IPProtocolTCP,
+			SrcIP:    src,
+			DstIP:    dst,
+		}
+
+		tcp := &layers.TCP{
+			SrcPort: layers.TCPPort(sport),
+			DstPort: layers.TCPPort(dport),
+			Seq:     seq,
+			SYN:     strings.Contains(flags, "S"),
+			ACK:     strings.Contains(flags, "A"),
+			RST:     strings.Contains(flags, "R"),
+		}
+
+		err := tcp.SetNetworkLayerForChecksum(ip)
+		require.NoError(t, err)
+
+		sbuf := gopacket.NewSerializeBuffer()
+		err = gopacket.SerializeLayers(sbuf, gopacket.SerializeOptions{
+			FixLengths:       true,
+			ComputeChecksums: true,
+		}, ip, tcp, gopacket.Payload(payload))
+		require.NoError(t, err, "Failed to serialize packet")
+
+		err = wr.WritePacket(gopacket.CaptureInfo{
+			Timestamp:     start.Add(time.Duration(i) * time.Millisecond),
+			CaptureLength: len(sbuf.Bytes()),
+			Length:        len(sbuf.Bytes()),
+		}, sbuf.Bytes())
+		require.NoError(t, err, "Failed to write packet")
+	}
+
+	segment(0, h1, h2, 40000, 80, 100, "S", "")
+	segment(1, h2, h1, 80, 40000, 500, "SA", "")
+	segment(2, h1, h2, 40000, 80, 101, "A", "")
+	segment(3, h1, h2, 40000, 80, 101, "A", "hello")
+	segment(4, h1, h2, 40000, 80, 101, "A", "hello") // Retransmission
+	segment(5, h2, h1, 80, 40000, 501, "R", "")
+
+	err = wr.Flush()
+	require.NoError(t, err, "Failed to flush writer")
+
+	flows, err := g.ReadFlows(buf)
+	require.NoError(t, err, "Failed to read flows")
+	require.Len(t, flows, 1)
+
+	f := flows[0]
+	require.Equal(t, "tcp", f.Protocol)
+	require.Equal(t, "10.0.0.1:40000", f.Source.String())
+	require.Equal(t, "10.0.0.2:80", f.Destination.String())
+	require.EqualValues(t, 6, f.Packets)
+	require.EqualValues(t, 1, f.Handshakes)
+	require.EqualValues(t, 1, f.Resets)
+	require.EqualValues(t, 1, f.Retransmissions)
+	require.Equal(t, 5*time.Millisecond, f.LastSeen.Sub(f.FirstSeen))
+	require.Contains(t, f.Interfaces, "h1/veth0")
+
+	out := &bytes.Buffer{}
+	err = flows.WriteTable(out)
+	require.NoError(t, err, "Failed to write table")
+	require.Contains(t, out.String(), "10.0.0.1:40000")
+
+	out.Reset()
+	err = flows.WriteJSON(out)
+	require.NoError(t, err, "Failed to write JSON")
+	require.Contains(t, out.String(), `"retransmissions": 1`)
+
+	// Flows are only tracked on request
+	require.Nil(t, g.NewCapture().Flows())
+	require.NotNil(t, g.NewCapture(co.Flows(true)).Flows())
+}
@@ -0,0 +1,218 @@
+// SPDX-FileCopyrightText: 2023 Steffen Vogel <post@steffenvogel.de>
+// SPDX-License-Identifier: Apache-2.0
+
+package gont
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/gopacket/gopacket"
+	"github.com/gopacket/gopacket/pcapgo"
+	"go.uber.org/zap"
+)
+
+// captureWriter writes the packets of one or more capture interfaces
+// to a single output.
+type captureWriter struct {
+	writer     captureFormatWriter
+	compressor captureCompressor // Only set for compressed outputs
+	output     io.Writer
+	closer     io.Closer
+	format     CaptureFormat
+	options    pcapgo.NgWriterOptions
+	logger     *zap.Logger
+
+	// Interfaces in the order in which they have been added to the writer
+	interfaces []*captureInterface
+	indices    map[*captureInterface]int
+
+	// Decryption secrets which have been written so far
+	secrets []decryptionSecret
+
+	// Only set for file outputs which can be rotated
+	file *captureFile
+
+	// Number of packet bytes handed to the format writer since the last flush
+	buffered int64
+}
+
+type decryptionSecret struct {
+	typ     uint32
+	payload []byte
+}
+
+// newCaptureWriter creates a new writer with ci as its first interface.
+// The closer is optional and closed together with the writer.
+func newCaptureWriter(wr io.Writer, closer io.Closer, format CaptureFormat, compression CaptureCompression, ci *captureInterface, opts pcapgo.NgWriterOptions, logger *zap.Logger) (*captureWriter, error) {
+	w := &captureWriter{
+		output:  wr,
+		closer:  closer,
+		format:  format,
+		options: opts,
+		indices: map[*captureInterface]int{},
+		logger:  logger,
+	}
+
+	var err error
+	if compression != CaptureCompressionNone {
+		if w.compressor, err = newCaptureCompressor(compression, wr); err != nil {
+			return nil, fmt.Errorf("failed to create compressor: %w", err)
+		}
+	}
+
+	if w.writer, err = newCaptureFormatWriter(format, w.stream(), ci.pcapInterface, opts, logger); err != nil {
+		return nil, fmt.Errorf("failed to create %s writer: %w", format, err)
+	}
+
+	// The first interface has always id 0
+	w.interfaces = append(w.interfaces, ci)
+	w.indices[ci] = 0
+	ci.writers = append(ci.writers, w)
+
+	return w, nil
+}
+
+func (w *captureWriter) addInterface(ci *captureInterface) error {
+	idx, err := w.writer.AddInterface(ci.pcapInterface)
+	if err != nil {
+		return fmt.Errorf("failed to add interface: %w", err)
+	}
+
+	w.interfaces = append(w.interfaces, ci)
+	w.indices[ci] = idx
+	ci.writers = append(ci.writers, w)
+
+	return nil
+}
+
+func (w *captureWriter) writePacket(ci *captureInterface, info gopacket.CaptureInfo, data []byte, opts pcapgo.NgPacketOptions) error {
+	info.InterfaceIndex = w.indices[ci]
+
+	if err := w.writer.WritePacketWithOptions(info, data, opts); err != nil {
+		return err
+	}
+
+	w.buffered += int64(len(data))
+
+	return nil
+}
+
+// size returns the number of bytes of the current file segment
+// including the packets which have not been flushed yet.
+func (w *captureWriter) size() int64 {
+	return w.file.written + w.buffered
+}
+
+func (w *captureWriter) writeDecryptionSecret(typ uint32, payload []byte) error {
+	w.secrets = append(w.secrets, decryptionSecret{typ, payload})
+
+	return w.writer.WriteDecryptionSecretsBlock(typ, payload)
+}
+
+func (w *captureWriter) writeStats(ci *captureInterface, stats pcapgo.NgInterfaceStatistics) error {
+	return w.writer.WriteInterfaceStats(w.indices[ci], stats)
+}
+
+func (w *captureWriter) Flush() error {
+	if err := w.writer.Flush(); err != nil {
+		return err
+	}
+
+	if w.compressor != nil {
+		if err := w.compressor.Flush(); err != nil {
+			return err
+		}
+	}
+
+	w.buffered = 0
+
+	return nil
+}
+
+func (w *captureWriter) Close() error {
+	if err := w.finish(); err != nil {
+		return err
+	}
+
+	if w.closer == nil {
+		return nil
+	}
+
+	return w.closer.Close()
+}
+
+// stream returns the writer to which the formatted packets are written.
+func (w *captureWriter) stream() io.Writer {
+	if w.compressor != nil {
+		return w.compressor
+	}
+
+	return w.output
+}
+
+// finish flushes all buffered data and terminates the compressed stream.
+func (w *captureWriter) finish() error {
+	if err := w.writer.Flush(); err != nil {
+		return fmt.Errorf("failed to flush: %w", err)
+	}
+
+	if w.compressor != nil {
+		if err := w.compressor.Close(); err != nil {
+			return fmt.Errorf("failed to close compressor: %w", err)
+		}
+	}
+
+	w.buffered = 0
+
+	return nil
+}
+
+// reset starts a new section after a call to finish() by writing the
+// file header, all interface descriptions and decryption secrets again.
+// The interface indices remain unchanged.
+func (w *captureWriter) reset() error {
+	if w.compressor != nil {
+		w.compressor.Reset(w.output)
+	}
+
+	var err error
+	w.writer, err = w.newFormatWriter(w.stream())
+
+	return err
+}
+
+// writeHeader writes the file header, all interface descriptions and
+// decryption secrets to wr. It is used to bring late-joining
+// viewers of a stream up to date.
+func (w *captureWriter) writeHeader(wr io.Writer) error {
+	fw, err := w.newFormatWriter(wr)
+	if err != nil {
+		return err
+	}
+
+	return fw.Flush()
+}
+
+// newFormatWriter creates a new format writer which has all interfaces
+// of the writer added in the same order and all decryption secrets written.
+func (w *captureWriter) newFormatWriter(wr io.Writer) (captureFormatWriter, error) {
+	fw, err := newCaptureFormatWriter(w.format, wr, w.interfaces[0].pcapInterface, w.options, w.logger)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s writer: %w", w.format, err)
+	}
+
+	for _, ci := range w.interfaces[1:] {
+		if _, err := fw.AddInterface(ci.pcapInterface); err != nil {
+			return nil, fmt.Errorf("failed to add interface: %w", err)
+		}
+	}
+
+	for _, s := range w.secrets {
+		if err := fw.WriteDecryptionSecretsBlock(s.typ, s.payload); err != nil {
+			return nil, fmt.Errorf("failed to write decryption secret: %w", err)
+		}
+	}
+
+	return fw, nil
+}
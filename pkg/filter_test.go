@@ -6,10 +6,13 @@ package gont_test
 import (
 	"net"
 	"testing"
+	"time"
 
 	g "cunicu.li/gont/v2/pkg"
 	o "cunicu.li/gont/v2/pkg/options"
 	fo "cunicu.li/gont/v2/pkg/options/filters"
+	to "cunicu.li/gont/v2/pkg/options/trace"
+	"cunicu.li/gont/v2/pkg/trace"
 	"github.com/stretchr/testify/require"
 	"golang.org/x/sys/unix"
 )
@@ -91,3 +94,246 @@ func TestFilterIPv6(t *testing.T) {
 	_, err = h1.Ping(h3)
 	require.Error(t, err, "Succeeded to ping h3")
 }
+
+func TestFilterRuleBuilder(t *testing.T) {
+	n, err := g.NewNetwork(*nname)
+	require.NoError(t, err, "Failed to create network")
+	defer n.MustClose()
+
+	sw, err := n.AddSwitch("sw")
+	require.NoError(t, err, "Failed to create switch")
+
+	h1, err := n.AddHost("h1",
+		fo.Rule().
+			From("10.0.3.0/24").
+			ICMPType(8). // Echo request
+			CTState(fo.CTNew).
+			Counter().
+			Log("blocked ").
+			Drop(),
+		fo.Rule().
+			Proto(fo.TCP).
+			DPortRange(1000, 2000).
+			Reject(fo.RejectAdminProhibited),
+		g.NewInterface("veth0", sw,
+			o.AddressIP("10.0.1.1/16")))
+	require.NoError(t, err, "Failed to create host")
+
+	h2, err := n.AddHost("h2",
+		g.NewInterface("veth0", sw,
+			o.AddressIP("10.0.2.1/16")))
+	require.NoError(t, err, "Failed to create host")
+
+	h3, err := n.AddHost("h3",
+		g.NewInterface("veth0", sw,
+			o.AddressIP("10.0.3.1/16")))
+	require.NoError(t, err, "Failed to create host")
+
+	_, err = h2.Ping(h1)
+	require.NoError(t, err, "Failed to ping h1")
+
+	_, err = h3.Ping(h1)
+	require.Error(t, err, "Succeeded to ping h1")
+}
+
+func TestFilterRuleBuilderInvalid(t *testing.T) {
+	require.Panics(t, func() {
+		fo.Rule().DPort(443)
+	}, "Ports without protocol")
+
+	require.Panics(t, func() {
+		fo.Rule().From("10.0.0.1").To("fc00::1")
+	}, "Mixed address families")
+
+	require.Panics(t, func() {
+		fo.Rule().Proto(fo.UDP).ICMPType(8)
+	}, "ICMP type with UDP")
+
+	r := fo.Rule().Output().IPv6().ICMPType(128).Accept()
+	require.Equal(t, g.FilterOutput, r.Hook)
+}
+
+func TestFilterRuleBuilderRouter(t *testing.T) {
+	n, err := g.NewNetwork(*nname)
+	require.NoError(t, err, "Failed to create network")
+	defer n.MustClose()
+
+	sw, err := n.AddSwitch("sw")
+	require.NoError(t, err, "Failed to create switch")
+
+	r1, err := n.AddRouter("r1",
+		g.NewInterface("veth0", sw,
+			o.AddressIP("10.0.0.2/24"),
+			o.AddressIP("10.0.0.3/24")))
+	require.NoError(t, err, "Failed to create router")
+
+	h2, err := n.AddHost("h2",
+		g.NewInterface("veth0", sw,
+			o.AddressIP("10.0.0.4/24")))
+	require.NoError(t, err, "Failed to create host")
+
+	// All addresses of the router are matched
+	h1, err := n.AddHost("h1",
+		fo.Rule().Output().To(r1).ICMPType(8).Drop(),
+		g.NewInterface("veth0", sw,
+			o.AddressIP("10.0.0.1/24")))
+	require.NoError(t, err, "Failed to create host")
+
+	_, err = h1.Run("ping", "-c", "1", "-W", "1", "10.0.0.2")
+	require.Error(t, err, "Succeeded to ping first address of r1")
+
+	_, err = h1.Run("ping", "-c", "1", "-W", "1", "10.0.0.3")
+	require.Error(t, err, "Succeeded to ping second address of r1")
+
+	_, err = h1.Ping(h2)
+	require.NoError(t, err, "Failed to ping h2")
+}
+
+func TestFilterRuleHandles(t *testing.T) {
+	n, err := g.NewNetwork(*nname)
+	require.NoError(t, err, "Failed to create network")
+	defer n.MustClose()
+
+	sw, err := n.AddSwitch("sw")
+	require.NoError(t, err, "Failed to create switch")
+
+	h1, err := n.AddHost("h1",
+		g.NewInterface("veth0", sw,
+			o.AddressIP("10.0.0.1/24")))
+	require.NoError(t, err, "Failed to create host")
+
+	h2, err := n.AddHost("h2",
+		g.NewInterface("veth0", sw,
+			o.AddressIP("10.0.0.2/24")))
+	require.NoError(t, err, "Failed to create host")
+
+	// Block echo requests from h2 for a while
+	r := fo.Rule().From(h2).ICMPType(8).Drop()
+	hdl := h1.Filter.AddRule(r.Hook, r.Exprs...)
+
+	err = h1.Filter.Flush()
+	require.NoError(t, err, "Failed to flush rules")
+
+	require.Len(t, h1.Filter.Rules(), 1)
+
+	_, err = h2.PingWithOptions(h1, "ip", 3, time.Second, 100*time.Millisecond, false)
+	require.Error(t, err, "Succeeded to ping h1")
+
+	counters, err := h1.Filter.Counters()
+	require.NoError(t, err, "Failed to get counters")
+	require.EqualValues(t, 3, counters[hdl].Packets)
+
+	err = h1.Filter.DeleteRule(hdl)
+	require.NoError(t, err, "Failed to delete rule")
+
+	require.Empty(t, h1.Filter.Rules())
+
+	_, err = h2.Ping(h1)
+	require.NoError(t, err, "Failed to ping h1")
+
+	err = h1.Filter.DeleteRule(hdl)
+	require.Error(t, err, "Deleted rule twice")
+}
+
+func TestFilterLog(t *testing.T) {
+	events := make(chan trace.Event, 10)
+
+	tr := g.NewTracer(
+		to.ToChannel(events),
+	)
+
+	n, err := g.NewNetwork(*nname, tr)
+	require.NoError(t, err, "Failed to create network")
+	defer n.MustClose()
+
+	sw, err := n.AddSwitch("sw")
+	require.NoError(t, err, "Failed to create switch")
+
+	h2, err := n.AddHost("h2",
+		g.NewInterface("veth0", sw,
+			o.AddressIP("10.0.0.2/24")))
+	require.NoError(t, err, "Failed to create host")
+
+	h1, err := n.AddHost("h1",
+		o.FilterLog(true),
+		fo.Rule().From(h2).ICMPType(8).LogDrop("block-h2"),
+		g.NewInterface("veth0", sw,
+			o.AddressIP("10.0.0.1/24")))
+	require.NoError(t, err, "Failed to create host")
+
+	_, err = h2.PingWithOptions(h1, "ip", 1, time.Second, time.Second, false)
+	require.Error(t, err, "Succeeded to ping h1")
+
+	select {
+	case e := <-events:
+		require.Equal(t, "filter", e.Type)
+		require.Equal(t, "h1", e.Source)
+
+		l, ok := e.Data.(trace.FilterLog)
+		require.True(t, ok, "Unexpected event data")
+		require.Equal(t, "block-h2", l.Rule)
+		require.Equal(t, "input", l.Hook)
+		require.Equal(t, "veth0", l.InputInterface)
+		require.Equal(t, "icmpv4", l.Protocol)
+		require.True(t, l.Source.Equal(net.ParseIP("10.0.0.2")))
+		require.True(t, l.Destination.Equal(net.ParseIP("10.0.0.1")))
+
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "No filter log event received")
+	}
+}
+
+func TestFilterSet(t *testing.T) {
+	n, err := g.NewNetwork(*nname)
+	require.NoError(t, err, "Failed to create network")
+	defer n.MustClose()
+
+	sw, err := n.AddSwitch("sw")
+	require.NoError(t, err, "Failed to create switch")
+
+	h1, err := n.AddHost("h1",
+		g.FilterSet{
+			Name:     "blocklist",
+			Type:     g.FilterSetIPv4Addr,
+			Interval: true,
+			Elements: []any{"10.0.3.0/24"},
+		},
+		fo.Rule().
+			InSet("blocklist", fo.SetKeySourceIPv4).
+			Proto(fo.ICMP).
+			Drop(),
+		g.NewInterface("veth0", sw,
+			o.AddressIP("10.0.1.1/16")))
+	require.NoError(t, err, "Failed to create host")
+
+	h2, err := n.AddHost("h2",
+		g.NewInterface("veth0", sw,
+			o.AddressIP("10.0.2.1/16")))
+	require.NoError(t, err, "Failed to create host")
+
+	h3, err := n.AddHost("h3",
+		g.NewInterface("veth0", sw,
+			o.AddressIP("10.0.3.1/16")))
+	require.NoError(t, err, "Failed to create host")
+
+	_, err = h2.Ping(h1)
+	require.NoError(t, err, "Failed to ping h1")
+
+	_, err = h3.Ping(h1)
+	require.Error(t, err, "Succeeded to ping h1")
+
+	err = h1.Filter.SetDeleteElements("blocklist", "10.0.3.0/24")
+	require.NoError(t, err, "Failed to delete set elements")
+
+	err = h1.Filter.SetAddElements("blocklist", net.ParseIP("10.0.2.1"))
+	require.NoError(t, err, "Failed to add set elements")
+
+	_, err = h2.Ping(h1)
+	require.Error(t, err, "Succeeded to ping h1")
+
+	_, err = h3.Ping(h1)
+	require.NoError(t, err, "Failed to ping h1")
+
+	err = h1.Filter.SetAddElements("unknown", "10.0.2.1")
+	require.Error(t, err, "Added elements to unknown set")
+}
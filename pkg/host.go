@@ -8,10 +8,13 @@ import (
 	"net"
 	"path/filepath"
 
+	"cunicu.li/gont/v2/internal/utils"
 	nl "github.com/vishvananda/netlink"
 	"go.uber.org/zap"
 )
 
+var _ utils.AddressLookuper = (*Host)(nil)
+
 type HostOption interface {
 	ApplyHost(h *Host)
 }
@@ -23,7 +26,11 @@ type Host struct {
 
 	// Options
 	FilterRules []*FilterRule
+	FilterSets  []*FilterSet
+	FilterLog   bool // Report packets logged to FilterLogGroup to the tracer and captures
 	Routes      []*nl.Route
+
+	filterLog *filterLogReader
 }
 
 // Options
@@ -93,32 +100,61 @@ func (n *Network) AddHost(name string, opts ...Option) (h *Host, err error) {
 		return nil, fmt.Errorf("failed to setup nftables: %w", err)
 	}
 
+	// Sets must exist before rules referencing them
+	for _, s := range h.FilterSets {
+		if err := h.Filter.AddSet(s); err != nil {
+			return nil, fmt.Errorf("failed to add filter set: %w", err)
+		}
+	}
+
 	for _, r := range h.FilterRules {
-		h.Filter.AddRule(r.Hook, r.Exprs...)
+		r.Handle = h.Filter.AddRule(r.Hook, r.Exprs...)
 	}
 
 	if err := h.Filter.Flush(); err != nil {
 		return nil, fmt.Errorf("failed to configure nftables: %w", err)
 	}
 
+	if h.FilterLog {
+		if err := h.startFilterLog(); err != nil {
+			return nil, fmt.Errorf("failed to start filter log: %w", err)
+		}
+	}
+
 	return h, nil
 }
 
+func (h *Host) Close() error {
+	if err := h.stopFilterLog(); err != nil {
+		return err
+	}
+
+	return h.BaseNode.Close()
+}
+
+func (h *Host) Teardown() error {
+	if err := h.stopFilterLog(); err != nil {
+		return err
+	}
+
+	return h.BaseNode.Teardown()
+}
+
 // ConfigureLinks adds links to other nodes which
 // have been configured by functional options
 func (h *Host) ConfigureLinks() error {
 	for _, intf := range h.ConfiguredInterfaces {
-		peerDev := fmt.Sprintf("veth-%s", h.Name())
+		if intf.Bond != nil {
+			if err := h.AddBond(intf); err != nil {
+				return err
+			}
 
-		right := &Interface{
-			Name: peerDev,
-			Node: intf.Node,
+			continue
 		}
 
-		left := intf
-		left.Node = h
+		peerDev := fmt.Sprintf("veth-%s", h.Name())
 
-		if err := h.network.AddLink(left, right); err != nil {
+		if err := h.linkInterface(intf, peerDev); err != nil {
 			return err
 		}
 	}
@@ -126,6 +162,25 @@ func (h *Host) ConfigureLinks() error {
 	return nil
 }
 
+// linkInterface connects the interface to a new interface
+// named peerDev in the node which has been configured as the peer.
+func (h *Host) linkInterface(intf *Interface, peerDev string) error {
+	right := &Interface{
+		Name: peerDev,
+		Node: intf.Node,
+	}
+
+	// Bridge port settings apply to the switch's side of the link
+	if _, ok := intf.Node.(*Switch); ok {
+		right.BridgePort = intf.BridgePort
+	}
+
+	left := intf
+	left.Node = h
+
+	return h.network.AddLink(left, right)
+}
+
 func (h *Host) ConfigureInterface(i *Interface) error {
 	h.logger.Info("Configuring interface", zap.Any("intf", i))
 
@@ -138,6 +193,19 @@ func (h *Host) ConfigureInterface(i *Interface) error {
 		}
 	}
 
+	// Enslave the interface to its VRF before adding addresses
+	// so that connected routes are installed in the VRF's table
+	if i.VRF != nil {
+		h.logger.Info("Attaching interface to VRF",
+			zap.Any("intf", i),
+			zap.String("vrf", i.VRF.Name),
+		)
+
+		if err := h.nlHandle.LinkSetMaster(i.Link, i.VRF.Link); err != nil {
+			return fmt.Errorf("failed to attach interface to VRF: %w", err)
+		}
+	}
+
 	for _, addr := range i.Addresses {
 		if err := i.AddAddress(&addr); err != nil {
 			return fmt.Errorf("failed to add link address: %w", err)
@@ -157,33 +225,37 @@ func (h *Host) Traceroute(o *Host, opts ...any) error {
 	return err
 }
 
+// LookupAddress returns the first address of the host for the network "ip", "ip4" or "ip6".
 func (h *Host) LookupAddress(n string) *net.IPAddr {
+	if addrs := h.LookupAddresses(n); len(addrs) > 0 {
+		return addrs[0]
+	}
+
+	return nil
+}
+
+// LookupAddresses returns all addresses of the host for the network "ip", "ip4" or "ip6".
+func (h *Host) LookupAddresses(n string) []*net.IPAddr {
+	addrs := []*net.IPAddr{}
+
 	for _, i := range h.Interfaces {
 		if i.IsLoopback() {
 			continue
 		}
 
 		for _, a := range i.Addresses {
-			ip := &net.IPAddr{
-				IP: a.IP,
-			}
-
 			isV4 := len(a.IP.To4()) == net.IPv4len
 
-			switch n {
-			case "ip":
-				return ip
-			case "ip4":
-				if isV4 {
-					return ip
-				}
-			case "ip6":
-				if !isV4 {
-					return ip
-				}
+			switch {
+			case n == "ip",
+				n == "ip4" && isV4,
+				n == "ip6" && !isV4:
+				addrs = append(addrs, &net.IPAddr{
+					IP: a.IP,
+				})
 			}
 		}
 	}
 
-	return nil
+	return addrs
 }
@@ -4,10 +4,25 @@
 package gont
 
 import (
+	"cmp"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
 	nft "github.com/google/nftables"
 	"github.com/google/nftables/expr"
+	"github.com/google/nftables/userdata"
 )
 
+var errUnknownFilterRule = errors.New("unknown filter rule")
+
+// filterRuleCommentPrefix is the prefix of the comments
+// which identify rules added via Filter.AddRule().
+const filterRuleCommentPrefix = "gont:"
+
 type FilterHook int
 
 const (
@@ -16,10 +31,20 @@ const (
 	FilterForward
 )
 
+// FilterRuleHandle identifies a rule added via Filter.AddRule().
+type FilterRuleHandle uint64
+
 type FilterRule struct {
 	Exprs []expr.Any
 
-	Hook FilterHook
+	Hook   FilterHook
+	Handle FilterRuleHandle // Assigned by Filter.AddRule()
+}
+
+// FilterCounter holds the number of packets and bytes which matched a rule.
+type FilterCounter struct {
+	Packets uint64
+	Bytes   uint64
 }
 
 func (fr FilterRule) ApplyHost(h *Host) {
@@ -35,6 +60,13 @@ type Filter struct {
 	Input   *nft.Chain
 	Output  *nft.Chain
 	Forward *nft.Chain
+
+	rules      map[FilterRuleHandle]*FilterRule
+	rulesLock  sync.Mutex
+	nextHandle FilterRuleHandle
+
+	sets     map[string]*nft.Set
+	setsLock sync.Mutex
 }
 
 func NewFilter(c *nft.Conn) (*Filter, error) {
@@ -42,6 +74,9 @@ func NewFilter(c *nft.Conn) (*Filter, error) {
 		conn: c,
 
 		Family: nft.TableFamilyINet,
+
+		rules: map[FilterRuleHandle]*FilterRule{},
+		sets:  map[string]*nft.Set{},
 	}
 
 	t := &nft.Table{
@@ -86,22 +121,185 @@ func NewFilter(c *nft.Conn) (*Filter, error) {
 	return flt, c.Flush()
 }
 
-func (f *Filter) AddRule(h FilterHook, exprs ...expr.Any) {
-	var chain *nft.Chain
+// AddRule appends a rule to the chain of the hook.
+// The rule becomes active with the next call to Flush().
+//
+// Unless the rule already contains a counter,
+// a counter is inserted in front of its verdict for Counters().
+//
+// Anonymous sets of FilterAnonymousSet lookups are added along with the rule.
+// Invalid set elements panic.
+func (f *Filter) AddRule(h FilterHook, exprs ...expr.Any) FilterRuleHandle {
+	f.rulesLock.Lock()
+	defer f.rulesLock.Unlock()
+
+	kexprs := slices.Clone(exprs)
+	for i, e := range kexprs {
+		if l, ok := e.(*FilterAnonymousSet); ok {
+			lookup, err := f.addAnonymousSet(l)
+			if err != nil {
+				panic(fmt.Errorf("failed to add anonymous set: %w", err))
+			}
+
+			kexprs[i] = lookup
+		}
+	}
+
+	f.nextHandle++
+	hdl := f.nextHandle
+
+	f.rules[hdl] = &FilterRule{
+		Exprs:  exprs,
+		Hook:   h,
+		Handle: hdl,
+	}
+
+	f.conn.AddRule(&nft.Rule{
+		Table:    f.Table,
+		Chain:    f.chain(h),
+		Exprs:    withCounter(kexprs),
+		UserData: userdata.AppendString(nil, userdata.TypeComment, filterRuleCommentPrefix+strconv.FormatUint(uint64(hdl), 10)),
+	})
+
+	return hdl
+}
+
+// DeleteRule removes a rule which has been added via AddRule() and commits the change.
+//
+// Only committed rules can be deleted. The commit includes all other
+// pending changes like rules which have been added since the last Flush().
+func (f *Filter) DeleteRule(hdl FilterRuleHandle) error {
+	rules, err := f.kernelRules()
+	if err != nil {
+		return err
+	}
+
+	r, ok := rules[hdl]
+	if !ok {
+		return fmt.Errorf("%w: %d", errUnknownFilterRule, hdl)
+	}
+
+	if err := f.conn.DelRule(r); err != nil {
+		return err
+	}
+
+	if err := f.conn.Flush(); err != nil {
+		return fmt.Errorf("failed to delete rule: %w", err)
+	}
+
+	f.rulesLock.Lock()
+	delete(f.rules, hdl)
+	f.rulesLock.Unlock()
+
+	return nil
+}
+
+// Rules returns all rules which have been added via AddRule() ordered by their handle.
+//
+// The rules include pending ones which have not been committed via Flush() yet.
+// Counters() covers only the committed rules.
+func (f *Filter) Rules() []*FilterRule {
+	f.rulesLock.Lock()
+	defer f.rulesLock.Unlock()
+
+	rules := []*FilterRule{}
+	for _, r := range f.rules {
+		rules = append(rules, r)
+	}
+
+	slices.SortFunc(rules, func(a, b *FilterRule) int {
+		return cmp.Compare(a.Handle, b.Handle)
+	})
+
+	return rules
+}
+
+// Counters returns the number of packets and bytes which matched
+// each of the committed rules which have been added via AddRule().
+func (f *Filter) Counters() (map[FilterRuleHandle]FilterCounter, error) {
+	rules, err := f.kernelRules()
+	if err != nil {
+		return nil, err
+	}
+
+	counters := map[FilterRuleHandle]FilterCounter{}
+
+	for hdl, r := range rules {
+		for _, e := range r.Exprs {
+			if c, ok := e.(*expr.Counter); ok {
+				counters[hdl] = FilterCounter{
+					Packets: c.Packets,
+					Bytes:   c.Bytes,
+				}
+
+				break
+			}
+		}
+	}
+
+	return counters, nil
+}
+
+func (f *Filter) chain(h FilterHook) *nft.Chain {
 	switch h {
 	case FilterForward:
-		chain = f.Forward
+		return f.Forward
 	case FilterInput:
-		chain = f.Input
+		return f.Input
 	case FilterOutput:
-		chain = f.Output
+		return f.Output
 	}
 
-	f.conn.AddRule(&nft.Rule{
-		Table: f.Table,
-		Chain: chain,
-		Exprs: exprs,
-	})
+	return nil
+}
+
+// kernelRules fetches the rules which have been added via AddRule() from the kernel.
+func (f *Filter) kernelRules() (map[FilterRuleHandle]*nft.Rule, error) {
+	rules := map[FilterRuleHandle]*nft.Rule{}
+
+	for _, c := range []*nft.Chain{f.Input, f.Output, f.Forward} {
+		rs, err := f.conn.GetRules(f.Table, c)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get rules: %w", err)
+		}
+
+		for _, r := range rs {
+			comment, ok := userdata.GetString(r.UserData, userdata.TypeComment)
+			if !ok || !strings.HasPrefix(comment, filterRuleCommentPrefix) {
+				continue
+			}
+
+			hdl, err := strconv.ParseUint(strings.TrimPrefix(comment, filterRuleCommentPrefix), 10, 64)
+			if err != nil {
+				continue
+			}
+
+			r.Chain = c
+			rules[FilterRuleHandle(hdl)] = r
+		}
+	}
+
+	return rules, nil
+}
+
+// withCounter inserts a counter in front of the verdict of a rule
+// unless it has one already.
+func withCounter(exprs []expr.Any) []expr.Any {
+	for _, e := range exprs {
+		if _, ok := e.(*expr.Counter); ok {
+			return exprs
+		}
+	}
+
+	idx := len(exprs)
+	if idx > 0 {
+		switch exprs[idx-1].(type) {
+		case *expr.Verdict, *expr.Reject:
+			idx--
+		}
+	}
+
+	return slices.Insert(slices.Clone(exprs), idx, expr.Any(&expr.Counter{}))
 }
 
 func (f *Filter) Flush() error {
@@ -0,0 +1,350 @@
+// SPDX-FileCopyrightText: 2023 Steffen Vogel <post@steffenvogel.de>
+// SPDX-License-Identifier: Apache-2.0
+
+package gont
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"cunicu.li/gont/v2/pkg/trace"
+	"github.com/gopacket/gopacket"
+	"github.com/gopacket/gopacket/layers"
+	"github.com/mdlayher/netlink"
+	"go.uber.org/zap"
+	"golang.org/x/sys/unix"
+)
+
+// FilterLogGroup is the NFLOG group to which filter rules log packets
+// which are reported to the tracer and captures of a node.
+const FilterLogGroup uint16 = 100
+
+// Constants of the nfnetlink_log subsystem, see linux/netfilter/nfnetlink_log.h
+const (
+	nfulnlMsgPacket = 0
+	nfulnlMsgConfig = 1
+
+	nfulaPacketHdr      = 1
+	nfulaTimestamp      = 3
+	nfulaIfIndexInDev   = 4
+	nfulaIfIndexOutDev  = 5
+	nfulaPayload        = 9
+	nfulaPrefix         = 10
+	nfulaCfgCmd         = 1
+	nfulaCfgMode        = 2
+	nfulnlCfgCmdBind    = 1
+	nfulnlCopyPacket    = 2
+	nfulnlCopyRangeFull = 0xffff
+)
+
+//nolint:gochecknoglobals
+var filterLogHooks = map[uint8]string{
+	unix.NF_INET_PRE_ROUTING:  "prerouting",
+	unix.NF_INET_LOCAL_IN:     "input",
+	unix.NF_INET_FORWARD:      "forward",
+	unix.NF_INET_LOCAL_OUT:    "output",
+	unix.NF_INET_POST_ROUTING: "postrouting",
+}
+
+// packetComment is passed via gopacket.CaptureInfo.AncillaryData
+// and written as a comment option of the PCAPng packet block.
+type packetComment string
+
+// filterLogReader receives packets logged by filter rules via NFLOG
+// and forwards them to the tracer and captures of a host.
+type filterLogReader struct {
+	host    *Host
+	conn    *netlink.Conn
+	tracer  *Tracer
+	sources []*filterLogPacketSource
+	closed  atomic.Bool
+	done    chan struct{}
+	logger  *zap.Logger
+}
+
+func (h *Host) startFilterLog() error {
+	conn, err := netlink.Dial(unix.NETLINK_NETFILTER, &netlink.Config{
+		NetNS: int(h.NsHandle),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to open netlink socket: %w", err)
+	}
+
+	fl := &filterLogReader{
+		host:   h,
+		conn:   conn,
+		tracer: h.Tracer,
+		done:   make(chan struct{}),
+		logger: h.logger.Named("filter-log"),
+	}
+
+	if fl.tracer == nil {
+		fl.tracer = h.network.Tracer
+	}
+
+	if fl.tracer != nil && fl.tracer.stop == nil {
+		if err := fl.tracer.start(); err != nil {
+			return fmt.Errorf("failed to start tracer: %w", err)
+		}
+	}
+
+	captures := []*Capture{}
+	captures = append(captures, h.network.Captures...)
+	captures = append(captures, h.Captures...)
+
+	for _, c := range captures {
+		if c == nil {
+			continue
+		}
+
+		ps, err := c.startFilterLog(h)
+		if err != nil {
+			fl.closeSources()
+			conn.Close()
+
+			return fmt.Errorf("failed to start capturing filter logs: %w", err)
+		}
+
+		fl.sources = append(fl.sources, ps)
+	}
+
+	if err := fl.bind(FilterLogGroup); err != nil {
+		fl.closeSources()
+		conn.Close()
+
+		return err
+	}
+
+	h.filterLog = fl
+
+	go fl.run()
+
+	return nil
+}
+
+func (h *Host) stopFilterLog() error {
+	if h.filterLog == nil {
+		return nil
+	}
+
+	if err := h.filterLog.Close(); err != nil {
+		return fmt.Errorf("failed to close filter log: %w", err)
+	}
+
+	h.filterLog = nil
+
+	return nil
+}
+
+// Close stops the reader and the captures of the logged packets.
+func (fl *filterLogReader) Close() error {
+	fl.closed.Store(true)
+
+	err := fl.conn.Close()
+
+	fl.closeSources()
+
+	<-fl.done
+
+	return err
+}
+
+func (fl *filterLogReader) closeSources() {
+	for _, ps := range fl.sources {
+		ps.Close() //nolint:errcheck
+	}
+}
+
+// bind subscribes the socket to an NFLOG group and requests full packet copies.
+func (fl *filterLogReader) bind(group uint16) error {
+	mode := make([]byte, 6)
+	binary.BigEndian.PutUint32(mode[0:], nfulnlCopyRangeFull)
+	mode[4] = nfulnlCopyPacket
+
+	for _, attr := range []netlink.Attribute{
+		{Type: nfulaCfgCmd, Data: []byte{nfulnlCfgCmdBind}},
+		{Type: nfulaCfgMode, Data: mode},
+	} {
+		data, err := netlink.MarshalAttributes([]netlink.Attribute{attr})
+		if err != nil {
+			return err
+		}
+
+		if _, err := fl.conn.Execute(netlink.Message{
+			Header: netlink.Header{
+				Type:  netlink.HeaderType(unix.NFNL_SUBSYS_ULOG<<8 | nfulnlMsgConfig),
+				Flags: netlink.Request | netlink.Acknowledge,
+			},
+			Data: append(nfGenMsg(unix.AF_UNSPEC, group), data...),
+		}); err != nil {
+			return fmt.Errorf("failed to bind to NFLOG group %d: %w", group, err)
+		}
+	}
+
+	return nil
+}
+
+func (fl *filterLogReader) run() {
+	defer close(fl.done)
+
+	for {
+		msgs, err := fl.conn.Receive()
+		if err != nil {
+			if fl.closed.Load() {
+				return
+			}
+
+			// Messages have been lost as the socket buffer overran
+			if errors.Is(err, unix.ENOBUFS) {
+				fl.logger.Warn("Lost NFLOG messages", zap.Error(err))
+				continue
+			}
+
+			fl.logger.Error("Failed to receive NFLOG messages. Stop reading...", zap.Error(err))
+			return
+		}
+
+		for _, msg := range msgs {
+			if msg.Header.Type != netlink.HeaderType(unix.NFNL_SUBSYS_ULOG<<8|nfulnlMsgPacket) {
+				continue
+			}
+
+			if err := fl.handleMessage(msg); err != nil {
+				fl.logger.Warn("Failed to decode NFLOG message", zap.Error(err))
+			}
+		}
+	}
+}
+
+func (fl *filterLogReader) handleMessage(msg netlink.Message) error {
+	if len(msg.Data) < 4 {
+		return io.ErrUnexpectedEOF
+	}
+
+	ad, err := netlink.NewAttributeDecoder(msg.Data[4:])
+	if err != nil {
+		return err
+	}
+
+	ad.ByteOrder = binary.BigEndian
+
+	l := trace.FilterLog{
+		Node: fl.host.Name(),
+	}
+
+	var payload []byte
+	var hwProto uint16
+	ts := time.Now()
+
+	for ad.Next() {
+		switch ad.Type() {
+		case nfulaPacketHdr:
+			if b := ad.Bytes(); len(b) >= 3 {
+				hwProto = binary.BigEndian.Uint16(b[0:])
+				l.Hook = filterLogHooks[b[2]]
+			}
+		case nfulaTimestamp:
+			if b := ad.Bytes(); len(b) >= 16 {
+				sec := binary.BigEndian.Uint64(b[0:])
+				usec := binary.BigEndian.Uint64(b[8:])
+				ts = time.Unix(int64(sec), int64(usec)*1e3) //nolint:gosec
+			}
+		case nfulaIfIndexInDev:
+			l.InputInterface = fl.interfaceName(ad.Uint32())
+		case nfulaIfIndexOutDev:
+			l.OutputInterface = fl.interfaceName(ad.Uint32())
+		case nfulaPayload:
+			payload = ad.Bytes()
+		case nfulaPrefix:
+			l.Rule = strings.TrimSpace(strings.TrimRight(ad.String(), "\x00"))
+		}
+	}
+
+	if err := ad.Err(); err != nil {
+		return err
+	}
+
+	l.Length = len(payload)
+	decodeFiveTuple(&l, hwProto, payload)
+
+	msgText := fmt.Sprintf("Packet matched filter rule '%s' on %s hook", l.Rule, l.Hook)
+
+	if t := fl.tracer; t != nil {
+		t.newEvent(trace.Event{
+			Timestamp: ts,
+			Type:      "filter",
+			Level:     trace.InfoLevel,
+			Message:   msgText,
+			Source:    fl.host.Name(),
+			Data:      l,
+		})
+	}
+
+	for _, ps := range fl.sources {
+		ps.SourcePacket(payload, gopacket.CaptureInfo{
+			Timestamp:     ts,
+			Length:        len(payload),
+			CaptureLength: len(payload),
+			AncillaryData: []any{packetComment(msgText)},
+		})
+	}
+
+	return nil
+}
+
+func (fl *filterLogReader) interfaceName(idx uint32) string {
+	for _, i := range fl.host.Interfaces {
+		if i.Link != nil && i.Link.Attrs().Index == int(idx) {
+			return i.Name
+		}
+	}
+
+	return strconv.FormatUint(uint64(idx), 10)
+}
+
+func decodeFiveTuple(l *trace.FilterLog, hwProto uint16, payload []byte) {
+	var first gopacket.Decoder
+	switch layers.EthernetType(hwProto) {
+	case layers.EthernetTypeIPv4:
+		first = layers.LayerTypeIPv4
+	case layers.EthernetTypeIPv6:
+		first = layers.LayerTypeIPv6
+	default:
+		return
+	}
+
+	pkt := gopacket.NewPacket(payload, first, gopacket.DecodeOptions{
+		Lazy:   true,
+		NoCopy: true,
+	})
+
+	switch nl := pkt.NetworkLayer().(type) {
+	case *layers.IPv4:
+		l.Source, l.Destination = nl.SrcIP, nl.DstIP
+		l.Protocol = strings.ToLower(nl.Protocol.String())
+	case *layers.IPv6:
+		l.Source, l.Destination = nl.SrcIP, nl.DstIP
+		l.Protocol = strings.ToLower(nl.NextHeader.String())
+	}
+
+	switch tl := pkt.TransportLayer().(type) {
+	case *layers.TCP:
+		l.SourcePort, l.DestinationPort = uint16(tl.SrcPort), uint16(tl.DstPort)
+	case *layers.UDP:
+		l.SourcePort, l.DestinationPort = uint16(tl.SrcPort), uint16(tl.DstPort)
+	case *layers.SCTP:
+		l.SourcePort, l.DestinationPort = uint16(tl.SrcPort), uint16(tl.DstPort)
+	}
+}
+
+func nfGenMsg(family uint8, resID uint16) []byte {
+	b := []byte{family, unix.NFNETLINK_V0, 0, 0}
+	binary.BigEndian.PutUint16(b[2:], resID)
+	return b
+}
@@ -0,0 +1,327 @@
+// SPDX-FileCopyrightText: 2023 Steffen Vogel <post@steffenvogel.de>
+// SPDX-License-Identifier: Apache-2.0
+
+package gont
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+
+	"cunicu.li/gont/v2/internal/utils"
+	nft "github.com/google/nftables"
+	"github.com/google/nftables/binaryutil"
+	"github.com/google/nftables/expr"
+)
+
+var (
+	errUnknownFilterSet     = errors.New("unknown filter set")
+	errInvalidSetElement    = errors.New("invalid set element")
+	errFilterSetExists      = errors.New("filter set already exists")
+	errUnsupportedSetType   = errors.New("unsupported set type")
+	errSetElementNoInterval = errors.New("ranges require an interval set")
+)
+
+// FilterSetType is the type of the elements of a named set.
+type FilterSetType int
+
+const (
+	FilterSetIPv4Addr    FilterSetType = iota // ipv4_addr
+	FilterSetIPv6Addr                         // ipv6_addr
+	FilterSetInetService                      // inet_service (transport protocol ports)
+)
+
+func (t FilterSetType) keyType() (nft.SetDatatype, error) {
+	switch t {
+	case FilterSetIPv4Addr:
+		return nft.TypeIPAddr, nil
+	case FilterSetIPv6Addr:
+		return nft.TypeIP6Addr, nil
+	case FilterSetInetService:
+		return nft.TypeInetService, nil
+	default:
+		return nft.SetDatatype{}, fmt.Errorf("%w: %d", errUnsupportedSetType, t)
+	}
+}
+
+// FilterSet is a named set in the nftables table of a host
+// which can be matched by filter rules via filters.InSet().
+//
+// Elements can be given as net.IP, net.IPNet, *net.IPNet or string for address sets
+// and as uint16, int or [2]uint16 port ranges for service sets.
+// Prefixes and ranges require an interval set.
+type FilterSet struct {
+	Name     string
+	Type     FilterSetType
+	Interval bool
+	Elements []any
+}
+
+func (fs FilterSet) ApplyHost(h *Host) {
+	h.FilterSets = append(h.FilterSets, &fs)
+}
+
+// FilterAnonymousSet matches a packet field against the constant elements of an anonymous set.
+// The embedded lookup must load the field into its source register.
+//
+// Filter.AddRule() adds the set along with the rule. The kernel removes it with the rule.
+// The name of the set is ignored.
+type FilterAnonymousSet struct {
+	*expr.Lookup
+
+	Set FilterSet
+}
+
+// AddSet adds a named set including its initial elements.
+// The set becomes active with the next call to Flush().
+func (f *Filter) AddSet(s *FilterSet) error {
+	keyType, err := s.Type.keyType()
+	if err != nil {
+		return err
+	}
+
+	f.setsLock.Lock()
+	defer f.setsLock.Unlock()
+
+	if _, ok := f.sets[s.Name]; ok {
+		return fmt.Errorf("%w: %s", errFilterSetExists, s.Name)
+	}
+
+	set := &nft.Set{
+		Table:    f.Table,
+		Name:     s.Name,
+		KeyType:  keyType,
+		Interval: s.Interval,
+	}
+
+	elems, err := setElements(set, s.Elements...)
+	if err != nil {
+		return err
+	}
+
+	if err := f.conn.AddSet(set, elems); err != nil {
+		return fmt.Errorf("failed to add set: %w", err)
+	}
+
+	f.sets[s.Name] = set
+
+	return nil
+}
+
+// addAnonymousSet adds the anonymous set of a lookup and returns the lookup referencing it.
+func (f *Filter) addAnonymousSet(l *FilterAnonymousSet) (*expr.Lookup, error) {
+	keyType, err := l.Set.Type.keyType()
+	if err != nil {
+		return nil, err
+	}
+
+	set := &nft.Set{
+		Table:     f.Table,
+		KeyType:   keyType,
+		Interval:  l.Set.Interval,
+		Anonymous: true,
+		Constant:  true,
+	}
+
+	elems, err := setElements(set, l.Set.Elements...)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := f.conn.AddSet(set, elems); err != nil {
+		return nil, fmt.Errorf("failed to add set: %w", err)
+	}
+
+	lookup := *l.Lookup
+	lookup.SetName = set.Name
+	lookup.SetID = set.ID
+
+	return &lookup, nil
+}
+
+// SetAddElements adds elements to a named set and commits the change.
+func (f *Filter) SetAddElements(name string, elems ...any) error {
+	set, err := f.set(name)
+	if err != nil {
+		return err
+	}
+
+	vals, err := setElements(set, elems...)
+	if err != nil {
+		return err
+	}
+
+	if err := f.conn.SetAddElements(set, vals); err != nil {
+		return fmt.Errorf("failed to add set elements: %w", err)
+	}
+
+	return f.conn.Flush()
+}
+
+// SetDeleteElements removes elements from a named set and commits the change.
+func (f *Filter) SetDeleteElements(name string, elems ...any) error {
+	set, err := f.set(name)
+	if err != nil {
+		return err
+	}
+
+	vals, err := setElements(set, elems...)
+	if err != nil {
+		return err
+	}
+
+	if err := f.conn.SetDeleteElements(set, vals); err != nil {
+		return fmt.Errorf("failed to delete set elements: %w", err)
+	}
+
+	return f.conn.Flush()
+}
+
+func (f *Filter) set(name string) (*nft.Set, error) {
+	f.setsLock.Lock()
+	defer f.setsLock.Unlock()
+
+	set, ok := f.sets[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", errUnknownFilterSet, name)
+	}
+
+	return set, nil
+}
+
+// setElements converts elements into their key representation.
+// Elements of interval sets are converted into pairs of start and exclusive end keys.
+func setElements(set *nft.Set, elems ...any) ([]nft.SetElement, error) {
+	vals := []nft.SetElement{}
+
+	for _, elem := range elems {
+		start, end, err := setElementRange(set.KeyType, elem)
+		if err != nil {
+			return nil, err
+		}
+
+		if !set.Interval {
+			if string(start) != string(end) {
+				return nil, fmt.Errorf("%w: %v", errSetElementNoInterval, elem)
+			}
+
+			vals = append(vals, nft.SetElement{
+				Key: start,
+			})
+
+			continue
+		}
+
+		vals = append(vals, nft.SetElement{
+			Key: start,
+		})
+
+		// The end of an interval is exclusive.
+		// It is omitted if the interval reaches the end of the key space.
+		if next, ok := increment(end); ok {
+			vals = append(vals, nft.SetElement{
+				Key:         next,
+				IntervalEnd: true,
+			})
+		}
+	}
+
+	return vals, nil
+}
+
+// setElementRange returns the first and last key covered by an element.
+func setElementRange(keyType nft.SetDatatype, elem any) ([]byte, []byte, error) {
+	switch keyType {
+	case nft.TypeIPAddr, nft.TypeIP6Addr:
+		netw, err := setElementPrefix(elem)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		first, last := utils.AddressRange(netw)
+
+		if keyType == nft.TypeIPAddr {
+			first, last = first.To4(), last.To4()
+		} else if netw.IP.To4() != nil {
+			first, last = nil, nil
+		} else {
+			first, last = first.To16(), last.To16()
+		}
+
+		if first == nil || last == nil {
+			return nil, nil, fmt.Errorf("%w: address family of %v does not match set", errInvalidSetElement, elem)
+		}
+
+		return first, last, nil
+
+	case nft.TypeInetService:
+		var minPort, maxPort uint16
+
+		switch elem := elem.(type) {
+		case uint16:
+			minPort, maxPort = elem, elem
+		case int:
+			if elem < 0 || elem > 0xffff {
+				return nil, nil, fmt.Errorf("%w: port out of range: %d", errInvalidSetElement, elem)
+			}
+
+			minPort, maxPort = uint16(elem), uint16(elem)
+		case [2]uint16:
+			minPort, maxPort = elem[0], elem[1]
+		default:
+			return nil, nil, fmt.Errorf("%w: %T", errInvalidSetElement, elem)
+		}
+
+		return binaryutil.BigEndian.PutUint16(minPort), binaryutil.BigEndian.PutUint16(maxPort), nil
+	}
+
+	return nil, nil, fmt.Errorf("%w: %s", errUnsupportedSetType, keyType.Name)
+}
+
+func setElementPrefix(elem any) (*net.IPNet, error) {
+	switch elem := elem.(type) {
+	case *net.IPNet:
+		return &net.IPNet{
+			IP:   elem.IP.Mask(elem.Mask),
+			Mask: elem.Mask,
+		}, nil
+	case net.IPNet:
+		return setElementPrefix(&elem)
+	case net.IP:
+		bits := 8 * net.IPv6len
+		if ip4 := elem.To4(); ip4 != nil {
+			elem, bits = ip4, 8*net.IPv4len
+		}
+
+		return &net.IPNet{
+			IP:   elem,
+			Mask: net.CIDRMask(bits, bits),
+		}, nil
+	case string:
+		if _, netw, err := net.ParseCIDR(elem); err == nil {
+			return setElementPrefix(netw)
+		} else if ip := net.ParseIP(elem); ip != nil {
+			return setElementPrefix(ip)
+		}
+	}
+
+	return nil, fmt.Errorf("%w: %v", errInvalidSetElement, elem)
+}
+
+// increment returns the key incremented by one
+// or false if it overflows.
+func increment(key []byte) ([]byte, bool) {
+	i := new(big.Int).SetBytes(key)
+	i.Add(i, big.NewInt(1))
+
+	b := i.Bytes()
+	if len(b) > len(key) {
+		return nil, false
+	}
+
+	next := make([]byte, len(key))
+	copy(next[len(key)-len(b):], b)
+
+	return next, true
+}
@@ -37,6 +37,10 @@ type Interface struct {
 	LinkAttrs   nl.LinkAttrs
 	Addresses   []net.IPNet
 	Captures    []*Capture
+	Bond        *nl.Bond
+	BondMembers []*Interface
+	VRF         *VRF
+	BridgePort  BridgePort
 }
 
 func NewInterface(name string, opts ...Option) *Interface {
@@ -0,0 +1,367 @@
+// SPDX-FileCopyrightText: 2023 Steffen Vogel <post@steffenvogel.de>
+// SPDX-License-Identifier: Apache-2.0
+
+package gont
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/netip"
+	"slices"
+	"time"
+
+	"github.com/gopacket/gopacket"
+	"github.com/gopacket/gopacket/layers"
+	nl "github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
+)
+
+var (
+	errUnsupportedLinkType = errors.New("unsupported link-type")
+	errInvalidIPVersion    = errors.New("invalid IP version")
+)
+
+type ReplayOption interface {
+	ApplyReplay(o *ReplayOptions)
+}
+
+// ReplayOptions control how Interface.Replay() sends recorded packets.
+type ReplayOptions struct {
+	// Wait between packets according to their original timestamps
+	Timing bool
+
+	// Speed multiplier for the original timing
+	Speed float64
+
+	// Link-type of the recorded packets.
+	// Defaults to the link-type of the packet source or Ethernet.
+	LinkType layers.LinkType
+
+	// Replace the source MAC address with the address of the interface
+	SourceMAC bool
+
+	// Rewrite MAC and IP addresses in both source and destination fields
+	MACs map[string]net.HardwareAddr
+	IPs  map[netip.Addr]netip.Addr
+}
+
+// Inject sends raw frames out of the interface.
+//
+// Frames are expected to include an Ethernet header unless
+// the interface is a layer-3 device like a WireGuard or GRE tunnel.
+func (i *Interface) Inject(pkts ...[]byte) error {
+	s, err := i.openPacketSocket()
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	for _, pkt := range pkts {
+		if err := s.send(pkt); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Replay sends all packets from a packet source like a pcapgo.Reader out of the interface.
+//
+// By default, packets are sent as fast as possible.
+// The original timing and addresses can be adjusted with ReplayOptions.
+func (i *Interface) Replay(ctx context.Context, src gopacket.PacketDataSource, opts ...ReplayOption) error {
+	o := &ReplayOptions{
+		Speed:    1,
+		LinkType: layers.LinkTypeEthernet,
+		MACs:     map[string]net.HardwareAddr{},
+		IPs:      map[netip.Addr]netip.Addr{},
+	}
+
+	if lt, ok := src.(interface{ LinkType() layers.LinkType }); ok {
+		o.LinkType = lt.LinkType()
+	}
+
+	for _, opt := range opts {
+		opt.ApplyReplay(o)
+	}
+
+	s, err := i.openPacketSocket()
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	if o.LinkType != s.linkType && (o.LinkType != layers.LinkTypeEthernet || s.linkType != layers.LinkTypeRaw) {
+		return fmt.Errorf("%w: can not send %s packets via %s interface", errUnsupportedLinkType, o.LinkType, s.linkType)
+	}
+
+	var first time.Time
+	start := time.Now()
+
+	for {
+		data, ci, err := src.ReadPacketData()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+
+			return fmt.Errorf("failed to read packet: %w", err)
+		}
+
+		if o.Timing && o.Speed > 0 {
+			if first.IsZero() {
+				first = ci.Timestamp
+			}
+
+			offset := time.Duration(float64(ci.Timestamp.Sub(first)) / o.Speed)
+
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(time.Until(start.Add(offset))):
+			}
+		} else if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		data = o.rewrite(data, s.mac)
+
+		// Strip Ethernet header for layer-3 interfaces
+		if o.LinkType == layers.LinkTypeEthernet && s.linkType == layers.LinkTypeRaw {
+			if len(data) < 14 {
+				continue
+			}
+
+			data = data[14:]
+		}
+
+		if err := s.send(data); err != nil {
+			return err
+		}
+	}
+}
+
+// rewrite replaces MAC and IP addresses in place and updates the checksums incrementally.
+// Layers which can not be decoded, e.g. of truncated packets, are left unchanged.
+func (o *ReplayOptions) rewrite(data []byte, mac net.HardwareAddr) []byte {
+	if !o.SourceMAC && len(o.MACs) == 0 && len(o.IPs) == 0 {
+		return data
+	}
+
+	data = slices.Clone(data)
+
+	// Without copying, the fields of the decoded layers point into data
+	p := gopacket.NewPacket(data, o.LinkType, gopacket.DecodeOptions{
+		NoCopy: true,
+	})
+
+	// Old and new addresses of the pseudo-header of transport layer checksums
+	var oldAddrs, newAddrs []byte
+
+	for _, l := range p.Layers() {
+		switch l := l.(type) {
+		case *layers.Ethernet:
+			copyAddr(l.DstMAC, o.mapMAC(l.DstMAC))
+
+			if o.SourceMAC && mac != nil {
+				copyAddr(l.SrcMAC, mac)
+			} else {
+				copyAddr(l.SrcMAC, o.mapMAC(l.SrcMAC))
+			}
+
+		case *layers.ARP:
+			copyAddr(l.DstHwAddress, o.mapMAC(l.DstHwAddress))
+			copyAddr(l.SourceProtAddress, o.mapIP(l.SourceProtAddress))
+			copyAddr(l.DstProtAddress, o.mapIP(l.DstProtAddress))
+
+			if o.SourceMAC && mac != nil {
+				copyAddr(l.SourceHwAddress, mac)
+			} else {
+				copyAddr(l.SourceHwAddress, o.mapMAC(l.SourceHwAddress))
+			}
+
+		case *layers.IPv4:
+			if len(l.Contents) < 20 {
+				continue
+			}
+
+			addrs := l.Contents[12:20]
+			oldAddrs = slices.Clone(addrs)
+
+			copyAddr(addrs[0:4], o.mapIP(addrs[0:4]))
+			copyAddr(addrs[4:8], o.mapIP(addrs[4:8]))
+
+			newAddrs = addrs
+			updateChecksum(l.Contents[10:12], oldAddrs, newAddrs)
+
+		case *layers.IPv6:
+			if len(l.Contents) < 40 {
+				continue
+			}
+
+			addrs := l.Contents[8:40]
+			oldAddrs = slices.Clone(addrs)
+
+			copyAddr(addrs[0:16], o.mapIP(addrs[0:16]))
+			copyAddr(addrs[16:32], o.mapIP(addrs[16:32]))
+
+			newAddrs = addrs
+
+		case *layers.TCP:
+			if len(l.Contents) >= 18 {
+				updateChecksum(l.Contents[16:18], oldAddrs, newAddrs)
+			}
+
+		case *layers.UDP:
+			// A zero checksum indicates that the checksum is not used
+			if len(l.Contents) >= 8 && l.Checksum != 0 {
+				updateChecksum(l.Contents[6:8], oldAddrs, newAddrs)
+			}
+
+		case *layers.ICMPv6:
+			if len(l.Contents) >= 4 {
+				updateChecksum(l.Contents[2:4], oldAddrs, newAddrs)
+			}
+		}
+	}
+
+	return data
+}
+
+// copyAddr overwrites an address in place if the replacement has the same length.
+func copyAddr(dst, src []byte) {
+	if len(dst) == len(src) {
+		copy(dst, src)
+	}
+}
+
+// updateChecksum incrementally updates a one's complement checksum
+// after the 16-bit words in oldWords have been replaced by newWords.
+// See: RFC 1624
+func updateChecksum(csum, oldWords, newWords []byte) {
+	if len(oldWords) != len(newWords) || len(oldWords)%2 != 0 {
+		return
+	}
+
+	sum := uint32(^binary.BigEndian.Uint16(csum))
+
+	for i := 0; i < len(oldWords); i += 2 {
+		sum += uint32(^binary.BigEndian.Uint16(oldWords[i:]))
+		sum += uint32(binary.BigEndian.Uint16(newWords[i:]))
+	}
+
+	for sum > 0xffff {
+		sum = (sum & 0xffff) + (sum >> 16)
+	}
+
+	binary.BigEndian.PutUint16(csum, ^uint16(sum))
+}
+
+func (o *ReplayOptions) mapMAC(mac net.HardwareAddr) net.HardwareAddr {
+	if m, ok := o.MACs[mac.String()]; ok {
+		return m
+	}
+
+	return mac
+}
+
+func (o *ReplayOptions) mapIP(ip []byte) []byte {
+	addr, ok := netip.AddrFromSlice(ip)
+	if !ok {
+		return ip
+	}
+
+	if m, ok := o.IPs[addr.Unmap()]; ok {
+		return m.AsSlice()
+	}
+
+	return ip
+}
+
+// packetSocket is an AF_PACKET socket bound to the namespace of an interface.
+type packetSocket struct {
+	fd       int
+	ifIndex  int
+	linkType layers.LinkType
+	mac      net.HardwareAddr
+}
+
+func (i *Interface) openPacketSocket() (*packetSocket, error) {
+	link, err := i.Node.NetlinkHandle().LinkByName(i.Name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find link: %w", err)
+	}
+
+	s := &packetSocket{
+		ifIndex:  link.Attrs().Index,
+		linkType: linkLayerType(link),
+		mac:      link.Attrs().HardwareAddr,
+	}
+
+	// Layer-3 devices require the kernel to construct the link-layer header
+	typ := unix.SOCK_RAW
+	if s.linkType == layers.LinkTypeRaw {
+		typ = unix.SOCK_DGRAM
+	}
+
+	// The socket remains in the namespace in which it has been created.
+	// Protocol 0 prevents the socket from receiving any packets.
+	if err := i.Node.RunFunc(func() error {
+		s.fd, err = unix.Socket(unix.AF_PACKET, typ|unix.SOCK_CLOEXEC, 0)
+		return err
+	}); err != nil {
+		return nil, fmt.Errorf("failed to open packet socket: %w", err)
+	}
+
+	return s, nil
+}
+
+func (s *packetSocket) send(data []byte) error {
+	sa := &unix.SockaddrLinklayer{
+		Ifindex: s.ifIndex,
+	}
+
+	if s.linkType == layers.LinkTypeRaw {
+		if len(data) < 1 {
+			return fmt.Errorf("%w: empty packet", errInvalidIPVersion)
+		}
+
+		switch data[0] >> 4 {
+		case 4:
+			sa.Protocol = htons(unix.ETH_P_IP)
+		case 6:
+			sa.Protocol = htons(unix.ETH_P_IPV6)
+		default:
+			return fmt.Errorf("%w: %d", errInvalidIPVersion, data[0]>>4)
+		}
+	}
+
+	if err := unix.Sendto(s.fd, data, 0, sa); err != nil {
+		return fmt.Errorf("failed to send packet: %w", err)
+	}
+
+	return nil
+}
+
+func (s *packetSocket) Close() error {
+	return unix.Close(s.fd)
+}
+
+// linkLayerType returns the link-type of the frames sent and received by a link.
+// Layer-3 tunnel devices like WireGuard or GRE do not carry an Ethernet header.
+func linkLayerType(l nl.Link) layers.LinkType {
+	switch l.Attrs().EncapType {
+	case "ether", "loopback", "":
+		return layers.LinkTypeEthernet
+	default:
+		return layers.LinkTypeRaw
+	}
+}
+
+func htons(i uint16) uint16 {
+	return i<<8 | i>>8
+}
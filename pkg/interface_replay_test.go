@@ -0,0 +1,172 @@
+// SPDX-FileCopyrightText: 2023 Steffen Vogel <post@steffenvogel.de>
+// SPDX-License-Identifier: Apache-2.0
+
+package gont_test
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	g "cunicu.li/gont/v2/pkg"
+	"cunicu.li/gont/v2/pkg/match"
+	o "cunicu.li/gont/v2/pkg/options"
+	ro "cunicu.li/gont/v2/pkg/options/replay"
+	"github.com/gopacket/gopacket"
+	"github.com/gopacket/gopacket/layers"
+	"github.com/gopacket/gopacket/pcapgo"
+	"github.com/stretchr/testify/require"
+)
+
+func udpFrame(t *testing.T, src, dst net.IP, dport uint16, payload string) []byte {
+	eth := &layers.Ethernet{
+		SrcMAC:       net.HardwareAddr{0x02, 0, 0, 0, 0, 1},
+		DstMAC:       layers.EthernetBroadcast,
+		EthernetType: layers.EthernetTypeIPv6,
+	}
+
+	ip := &layers.IPv6{
+		Version:    6,
+		HopLimit:   64,
+		NextHeader: layers.IPProtocolUDP,
+		SrcIP:      src,
+		DstIP:      dst,
+	}
+
+	udp := &layers.UDP{
+		SrcPort: 12345,
+		DstPort: layers.UDPPort(dport),
+	}
+
+	err := udp.SetNetworkLayerForChecksum(ip)
+	require.NoError(t, err)
+
+	buf := gopacket.NewSerializeBuffer()
+	err = gopacket.SerializeLayers(buf, gopacket.SerializeOptions{
+		FixLengths:       true,
+		ComputeChecksums: true,
+	}, eth, ip, udp, gopacket.Payload(payload))
+	require.NoError(t, err, "Failed to serialize packet")
+
+	return buf.Bytes()
+}
+
+func TestInterfaceInjectReplay(t *testing.T) {
+	c := g.NewCapture()
+
+	n, err := g.NewNetwork(*nname, c)
+	require.NoError(t, err, "Failed to create network")
+	defer n.MustClose()
+
+	h1, err := n.AddHost("h1")
+	require.NoError(t, err, "Failed to add host")
+
+	h2, err := n.AddHost("h2")
+	require.NoError(t, err, "Failed to add host")
+
+	err = n.AddLink(
+		g.NewInterface("veth0", h1, o.AddressIP("fc::1/64")),
+		g.NewInterface("veth0", h2, o.AddressIP("fc::2/64")))
+	require.NoError(t, err, "Failed to add link")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// Inject a frame as is
+	frame := udpFrame(t, net.ParseIP("fc::1"), net.ParseIP("fc::2"), 1000, "inject")
+	errs := make(chan error, 1)
+
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		errs <- h1.Interface("veth0").Inject(frame)
+	}()
+
+	_, err = c.Expect(ctx, match.UDP().DPort(1000).Interface("h2/veth0").Contains([]byte("inject")))
+	require.NoError(t, err, "Failed to capture injected packet")
+
+	err = <-errs
+	require.NoError(t, err, "Failed to inject packet")
+
+	// Replay a recording whose addresses are mapped to the topology
+	rec := &bytes.Buffer{}
+	wr := pcapgo.NewWriter(rec)
+
+	err = wr.WriteFileHeader(1600, layers.LinkTypeEthernet)
+	require.NoError(t, err)
+
+	// A truncated packet which can not be fully decoded must not abort the replay
+	start := time.Now()
+	frame = udpFrame(t, net.ParseIP("2001:db8::1"), net.ParseIP("2001:db8::2"), 2000, "replay-truncated")
+	err = wr.WritePacket(gopacket.CaptureInfo{
+		Timestamp:     start,
+		CaptureLength: len(frame) - 8,
+		Length:        len(frame),
+	}, frame[:len(frame)-8])
+	require.NoError(t, err)
+
+	for i := 1; i <= 3; i++ {
+		frame := udpFrame(t, net.ParseIP("2001:db8::1"), net.ParseIP("2001:db8::2"), 2000, "replay-full")
+		err = wr.WritePacket(gopacket.CaptureInfo{
+			Timestamp:     start.Add(time.Duration(i) * 100 * time.Millisecond),
+			CaptureLength: len(frame),
+			Length:        len(frame),
+		}, frame)
+		require.NoError(t, err)
+	}
+
+	rd, err := pcapgo.NewReader(rec)
+	require.NoError(t, err)
+
+	replayed := match.UDP().From(h1).To(h2).DPort(2000).Interface("h2/veth0").Contains([]byte("replay-full"))
+
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		errs <- h1.Interface("veth0").Replay(ctx, rd,
+			ro.Speed(2),
+			ro.SourceMAC(true),
+			ro.MapIP(net.ParseIP("2001:db8::1"), h1),
+			ro.MapIP(net.ParseIP("2001:db8::2"), h2))
+	}()
+
+	pkts, err := c.ExpectAtLeast(ctx, replayed, 3)
+	require.NoError(t, err, "Failed to capture replayed packets")
+	require.Len(t, pkts, 3)
+
+	err = <-errs
+	require.NoError(t, err, "Failed to replay packets")
+
+	// The checksum must match the rewritten addresses
+	p := pkts[0].Decode(gopacket.Default)
+
+	ip, ok := p.Layer(layers.LayerTypeIPv6).(*layers.IPv6)
+	require.True(t, ok)
+
+	udp, ok := p.Layer(layers.LayerTypeUDP).(*layers.UDP)
+	require.True(t, ok)
+
+	csum := udp.Checksum
+
+	err = udp.SetNetworkLayerForChecksum(ip)
+	require.NoError(t, err)
+
+	buf := gopacket.NewSerializeBuffer()
+	err = gopacket.SerializeLayers(buf, gopacket.SerializeOptions{
+		ComputeChecksums: true,
+	}, udp, gopacket.Payload(udp.Payload))
+	require.NoError(t, err)
+
+	require.Equal(t, csum, udp.Checksum, "Invalid UDP checksum")
+}
+
+func TestReplayMapIPInvalid(t *testing.T) {
+	require.Panics(t, func() { ro.MapIP(net.ParseIP("10.0.0.1"), net.ParseIP("fc::1")) })
+	require.Panics(t, func() { ro.MapIP(net.ParseIP("fc::1"), "10.0.0.1") })
+	require.Panics(t, func() { ro.MapIP(net.ParseIP("10.0.0.1"), "10.0.0.0/24") })
+	require.Panics(t, func() {
+		_, pfx, _ := net.ParseCIDR("10.0.0.0/24")
+		ro.MapIP(net.ParseIP("10.0.0.1"), pfx)
+	})
+	require.NotPanics(t, func() { ro.MapIP(net.ParseIP("10.0.0.1"), "10.0.0.2") })
+}
@@ -28,9 +28,8 @@ type LinkOption interface {
 	ApplyLink(a *nl.LinkAttrs)
 }
 
-func (n *Network) AddLink(l, r *Interface, opts ...Option) error {
-	var err error
-
+// checkLinkEndpoints validates that two interfaces can be linked with each other.
+func checkLinkEndpoints(l, r *Interface) error {
 	if len(l.Name) > syscall.IFNAMSIZ-1 || len(r.Name) > syscall.IFNAMSIZ-1 {
 		return fmt.Errorf("%w: too long. max_len=%d", ErrInvalidName, syscall.IFNAMSIZ-1)
 	}
@@ -47,6 +46,16 @@ func (n *Network) AddLink(l, r *Interface, opts ...Option) error {
 		return errInvalidNetwork
 	}
 
+	return nil
+}
+
+func (n *Network) AddLink(l, r *Interface, opts ...Option) error {
+	var err error
+
+	if err := checkLinkEndpoints(l, r); err != nil {
+		return err
+	}
+
 	// Create Veth pair
 	n.logger.Info("Adding new veth pair",
 		zap.Any("left", l),
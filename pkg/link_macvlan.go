@@ -0,0 +1,143 @@
+// SPDX-FileCopyrightText: 2023 Steffen Vogel <post@steffenvogel.de>
+// SPDX-License-Identifier: Apache-2.0
+
+package gont
+
+import (
+	"errors"
+	"fmt"
+	"syscall"
+
+	"cunicu.li/gont/v2/internal/utils"
+	nl "github.com/vishvananda/netlink"
+	"go.uber.org/zap"
+	"golang.org/x/sys/unix"
+)
+
+var errMissingParentLink = errors.New("parent interface has not been created yet")
+
+type MacvlanOption interface {
+	ApplyMacvlan(mv *nl.Macvlan)
+}
+
+type IPVlanOption interface {
+	ApplyIPVlan(iv *nl.IPVlan)
+}
+
+// AddMACVLAN adds a MACVLAN interface on top of an existing parent interface.
+//
+// The child interface may belong to a different node than its parent.
+// By default, the MACVLAN interface operates in bridge mode.
+func (n *Network) AddMACVLAN(parent, child *Interface, opts ...Option) error {
+	n.logger.Info("Adding new MACVLAN interface",
+		zap.Any("parent", parent),
+		zap.Any("child", child),
+	)
+
+	mv := &nl.Macvlan{
+		Mode: nl.MACVLAN_MODE_BRIDGE,
+	}
+
+	for _, opt := range opts {
+		if opt, ok := opt.(MacvlanOption); ok {
+			opt.ApplyMacvlan(mv)
+		}
+	}
+
+	return n.addChildLink(parent, child, mv)
+}
+
+// AddIPVLAN adds an IPVLAN interface on top of an existing parent interface.
+//
+// The child interface may belong to a different node than its parent.
+// By default, the IPVLAN interface operates in L2 mode.
+func (n *Network) AddIPVLAN(parent, child *Interface, opts ...Option) error {
+	n.logger.Info("Adding new IPVLAN interface",
+		zap.Any("parent", parent),
+		zap.Any("child", child),
+	)
+
+	iv := &nl.IPVlan{
+		Mode: nl.IPVLAN_MODE_L2,
+	}
+
+	for _, opt := range opts {
+		if opt, ok := opt.(IPVlanOption); ok {
+			opt.ApplyIPVlan(iv)
+		}
+	}
+
+	return n.addChildLink(parent, child, iv)
+}
+
+// addChildLink creates the link l as a child of the parent interface
+// and moves it into the namespace of the child's node.
+func (n *Network) addChildLink(parent, child *Interface, l nl.Link) error {
+	var err error
+
+	if len(child.Name) > syscall.IFNAMSIZ-1 {
+		return fmt.Errorf("%w: too long. max_len=%d", ErrInvalidName, syscall.IFNAMSIZ-1)
+	}
+
+	if parent.Node == nil || child.Node == nil {
+		return errMissingNode
+	}
+
+	if parent.Link == nil {
+		return errMissingParentLink
+	}
+
+	if parent.Node.Network() != child.Node.Network() {
+		return errInvalidNetwork
+	}
+
+	pHandle := parent.Node.NetlinkHandle()
+	cHandle := child.Node.NetlinkHandle()
+
+	attrs := l.Attrs()
+	attrs.ParentIndex = parent.Link.Attrs().Index
+
+	// Similar to veth pairs, we create the child interface with a
+	// temporary name in the parent's namespace and rename it after
+	// it has been moved to the target namespace.
+	if parent.Node == child.Node {
+		attrs.Name = child.Name
+	} else {
+		attrs.Name = utils.RandStringRunes(unix.IFNAMSIZ - 1) // temporary name
+	}
+
+	if err := pHandle.LinkAdd(l); err != nil {
+		return fmt.Errorf("failed to add link: %w", err)
+	}
+
+	if parent.Node != child.Node {
+		tmpLink, err := pHandle.LinkByName(attrs.Name)
+		if err != nil {
+			return fmt.Errorf("failed to find interface %s: %w", attrs.Name, err)
+		}
+
+		if err := pHandle.LinkSetNsFd(tmpLink, int(child.Node.NetNSHandle())); err != nil {
+			pHandle.LinkDel(tmpLink) //nolint:errcheck
+			return fmt.Errorf("failed to move interface to namespace: %w", err)
+		}
+
+		if tmpLink, err = cHandle.LinkByName(attrs.Name); err != nil {
+			return fmt.Errorf("failed to find interface %s: %w", attrs.Name, err)
+		}
+
+		if err := cHandle.LinkSetName(tmpLink, child.Name); err != nil {
+			cHandle.LinkDel(tmpLink) //nolint:errcheck
+			return fmt.Errorf("failed to rename interface: %w", err)
+		}
+	}
+
+	if child.Link, err = cHandle.LinkByName(child.Name); err != nil {
+		return fmt.Errorf("failed to find interface %s: %w", child.Name, err)
+	}
+
+	if err := child.Node.ConfigureInterface(child); err != nil {
+		return fmt.Errorf("failed to configure interface: %w", err)
+	}
+
+	return nil
+}
@@ -4,9 +4,11 @@
 package gont_test
 
 import (
+	"fmt"
 	"testing"
 
 	g "cunicu.li/gont/v2/pkg"
+	o "cunicu.li/gont/v2/pkg/options"
 	"github.com/stretchr/testify/require"
 )
 
@@ -26,3 +28,154 @@ func TestLink(t *testing.T) {
 		g.NewInterface("veth0", h2))
 	require.NoError(t, err, "Failed to link nodes")
 }
+
+// testTunnel creates two hosts with an IPv4-only underlay network
+// and checks the IPv6-only overlay network established by addTunnel.
+//
+//	h1 <-> sw <-> h2
+func testTunnel(t *testing.T, addTunnel func(n *g.Network, l, r *g.Interface) error) {
+	n, err := g.NewNetwork(*nname)
+	require.NoError(t, err, "Failed to create network")
+	defer n.MustClose()
+
+	sw, err := n.AddSwitch("sw")
+	require.NoError(t, err, "Failed to create switch")
+
+	h1, err := n.AddHost("h1",
+		g.NewInterface("veth0", sw,
+			o.AddressIP("10.0.0.1/24")))
+	require.NoError(t, err, "Failed to create host")
+
+	h2, err := n.AddHost("h2",
+		g.NewInterface("veth0", sw,
+			o.AddressIP("10.0.0.2/24")))
+	require.NoError(t, err, "Failed to create host")
+
+	err = addTunnel(n,
+		g.NewInterface("tun0", h1,
+			o.AddressIP("fc::1/64")),
+		g.NewInterface("tun0", h2,
+			o.AddressIP("fc::2/64")))
+	require.NoError(t, err, "Failed to add tunnel")
+
+	_, err = h1.PingWithNetwork(h2, "ip6")
+	require.NoError(t, err, "Failed to ping via tunnel")
+}
+
+func TestLinkVXLAN(t *testing.T) {
+	testTunnel(t, func(n *g.Network, l, r *g.Interface) error {
+		return n.AddVXLAN(l, r, 1000)
+	})
+}
+
+func TestLinkGRE(t *testing.T) {
+	testTunnel(t, func(n *g.Network, l, r *g.Interface) error {
+		return n.AddGRE(l, r, o.GREKey(1234))
+	})
+}
+
+func TestLinkWireGuard(t *testing.T) {
+	testTunnel(t, func(n *g.Network, l, r *g.Interface) error {
+		return n.AddWireGuard(l, r, o.WireGuardListenPort(1234))
+	})
+}
+
+// TestLinkWireGuardMultiple checks that a node can have multiple
+// WireGuard devices which listen on separate default ports.
+//
+//	h1 <-> sw <-> h2
+func TestLinkWireGuardMultiple(t *testing.T) {
+	n, err := g.NewNetwork(*nname)
+	require.NoError(t, err, "Failed to create network")
+	defer n.MustClose()
+
+	sw, err := n.AddSwitch("sw")
+	require.NoError(t, err, "Failed to create switch")
+
+	h1, err := n.AddHost("h1",
+		g.NewInterface("veth0", sw,
+			o.AddressIP("10.0.0.1/24")))
+	require.NoError(t, err, "Failed to create host")
+
+	h2, err := n.AddHost("h2",
+		g.NewInterface("veth0", sw,
+			o.AddressIP("10.0.0.2/24")))
+	require.NoError(t, err, "Failed to create host")
+
+	for i, name := range []string{"wg0", "wg1"} {
+		err = n.AddWireGuard(
+			g.NewInterface(name, h1,
+				o.AddressIP("fc:%d::1/64", i)),
+			g.NewInterface(name, h2,
+				o.AddressIP("fc:%d::2/64", i)))
+		require.NoError(t, err, "Failed to add tunnel")
+	}
+
+	for i := range 2 {
+		_, err = h1.Run("ping", "-c", "1", "-W", "1", fmt.Sprintf("fc:%d::2", i))
+		require.NoError(t, err, "Failed to ping via tunnel %d", i)
+	}
+}
+
+// TestLinkMACVLAN creates a MACVLAN interface on top
+// of a switch port and moves it into a new host.
+//
+//	h1 <-> sw <~> h2
+func TestLinkMACVLAN(t *testing.T) {
+	n, err := g.NewNetwork(*nname)
+	require.NoError(t, err, "Failed to create network")
+	defer n.MustClose()
+
+	sw, err := n.AddSwitch("sw")
+	require.NoError(t, err, "Failed to create switch")
+
+	h1, err := n.AddHost("h1",
+		g.NewInterface("veth0", sw,
+			o.AddressIP("10.0.0.1/24")))
+	require.NoError(t, err, "Failed to create host")
+
+	h2, err := n.AddHost("h2")
+	require.NoError(t, err, "Failed to create host")
+
+	err = n.AddMACVLAN(
+		sw.Interface("veth-h1"),
+		g.NewInterface("mv0", h2,
+			o.AddressIP("10.0.0.2/24")),
+		o.MACVLANModeBridge)
+	require.NoError(t, err, "Failed to add MACVLAN interface")
+
+	err = g.TestConnectivity(h1, h2)
+	require.NoError(t, err, "Failed to test connectivity between hosts")
+}
+
+// TestLinkIPVLAN creates an IPVLAN interface on top
+// of a host interface and moves it into a new host.
+//
+//	h1 <-> h2
+//	 `~> h3
+func TestLinkIPVLAN(t *testing.T) {
+	n, err := g.NewNetwork(*nname)
+	require.NoError(t, err, "Failed to create network")
+	defer n.MustClose()
+
+	h1, err := n.AddHost("h1")
+	require.NoError(t, err, "Failed to create host")
+
+	h2, err := n.AddHost("h2",
+		g.NewInterface("veth0", h1,
+			o.AddressIP("10.0.0.2/24")))
+	require.NoError(t, err, "Failed to create host")
+
+	h3, err := n.AddHost("h3")
+	require.NoError(t, err, "Failed to create host")
+
+	err = n.AddIPVLAN(
+		h1.Interface("veth-h2"),
+		g.NewInterface("ipvl0", h3,
+			o.AddressIP("10.0.0.3/24")),
+		o.IPVLANModeL2)
+	require.NoError(t, err, "Failed to add IPVLAN interface")
+
+	err = g.TestConnectivity(h2, h3)
+	require.NoError(t, err, "Failed to test connectivity between hosts")
+}
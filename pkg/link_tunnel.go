@@ -0,0 +1,202 @@
+// SPDX-FileCopyrightText: 2023 Steffen Vogel <post@steffenvogel.de>
+// SPDX-License-Identifier: Apache-2.0
+
+package gont
+
+import (
+	"errors"
+	"fmt"
+	"net"
+
+	nl "github.com/vishvananda/netlink"
+	"go.uber.org/zap"
+)
+
+const DefaultVXLANPort = 4789
+
+var errNoUnderlayAddress = errors.New("failed to find underlay addresses of a common family for tunnel endpoints")
+
+type VxlanOption interface {
+	ApplyVxlan(vx *nl.Vxlan)
+}
+
+type GretunOption interface {
+	ApplyGretun(gt *nl.Gretun)
+}
+
+// underlayNode is implemented by all nodes which can
+// serve as endpoints of a tunnel.
+type underlayNode interface {
+	underlayAddress(family string) net.IP
+}
+
+// tunnelLinkFunc creates a new tunnel device for the interface i.
+type tunnelLinkFunc func(i *Interface, local, remote net.IP) nl.Link
+
+// AddVXLAN connects the nodes of the interfaces l and r via a
+// point-to-point VXLAN tunnel using the VXLAN network identifier vni.
+func (n *Network) AddVXLAN(l, r *Interface, vni int, opts ...Option) error {
+	n.logger.Info("Adding new VXLAN tunnel",
+		zap.Any("left", l),
+		zap.Any("right", r),
+		zap.Int("vni", vni),
+	)
+
+	_, _, err := n.addTunnel(l, r, func(i *Interface, local, remote net.IP) nl.Link {
+		vx := &nl.Vxlan{
+			LinkAttrs: nl.LinkAttrs{
+				Name: i.Name,
+			},
+			VxlanId:  vni,
+			SrcAddr:  local,
+			Group:    remote,
+			Port:     DefaultVXLANPort,
+			Learning: true,
+		}
+
+		for _, opt := range opts {
+			if opt, ok := opt.(VxlanOption); ok {
+				opt.ApplyVxlan(vx)
+			}
+		}
+
+		return vx
+	})
+
+	return err
+}
+
+// AddGRE connects the nodes of the interfaces l and r via a
+// point-to-point layer-3 GRE tunnel.
+func (n *Network) AddGRE(l, r *Interface, opts ...Option) error {
+	n.logger.Info("Adding new GRE tunnel",
+		zap.Any("left", l),
+		zap.Any("right", r),
+	)
+
+	_, _, err := n.addTunnel(l, r, func(i *Interface, local, remote net.IP) nl.Link {
+		gt := &nl.Gretun{
+			LinkAttrs: nl.LinkAttrs{
+				Name: i.Name,
+			},
+			Local:    local,
+			Remote:   remote,
+			PMtuDisc: 1,
+		}
+
+		for _, opt := range opts {
+			if opt, ok := opt.(GretunOption); ok {
+				opt.ApplyGretun(gt)
+			}
+		}
+
+		return gt
+	})
+
+	return err
+}
+
+// addTunnel creates the tunnel devices returned by newLink
+// in the namespaces of the nodes of both interfaces.
+//
+// The tunnel endpoints are the first addresses of a common address family
+// which are assigned to the interfaces of both nodes.
+// They are returned to the caller for further configuration of the tunnel.
+func (n *Network) addTunnel(l, r *Interface, newLink tunnelLinkFunc) (net.IP, net.IP, error) {
+	if err := checkLinkEndpoints(l, r); err != nil {
+		return nil, nil, err
+	}
+
+	lAddr, rAddr, err := n.underlayAddresses(l.Node, r.Node)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, ep := range []struct {
+		intf          *Interface
+		local, remote net.IP
+	}{
+		{l, lAddr, rAddr},
+		{r, rAddr, lAddr},
+	} {
+		hdl := ep.intf.Node.NetlinkHandle()
+
+		if err := hdl.LinkAdd(newLink(ep.intf, ep.local, ep.remote)); err != nil {
+			return nil, nil, fmt.Errorf("failed to add link: %w", err)
+		}
+
+		if ep.intf.Link, err = hdl.LinkByName(ep.intf.Name); err != nil {
+			return nil, nil, fmt.Errorf("failed to find interface %s: %w", ep.intf.Name, err)
+		}
+	}
+
+	// Configure interface (link state, adding addresses)
+	for _, i := range []*Interface{l, r} {
+		if err := i.Node.ConfigureInterface(i); err != nil {
+			return nil, nil, fmt.Errorf("failed to configure endpoint: %w", err)
+		}
+	}
+
+	return lAddr, rAddr, nil
+}
+
+// underlayAddresses returns a pair of addresses of the same family
+// which are used by both nodes as local endpoints of a tunnel.
+func (n *Network) underlayAddresses(l, r Node) (net.IP, net.IP, error) {
+	lu, lok := l.(underlayNode)
+	ru, rok := r.(underlayNode)
+	if !lok || !rok {
+		return nil, nil, errNoUnderlayAddress
+	}
+
+	families := []string{}
+	if !n.IPv4Disabled {
+		families = append(families, "ip4")
+	}
+	if !n.IPv6Disabled {
+		families = append(families, "ip6")
+	}
+
+	for _, family := range families {
+		lAddr := lu.underlayAddress(family)
+		rAddr := ru.underlayAddress(family)
+
+		if lAddr != nil && rAddr != nil {
+			return lAddr, rAddr, nil
+		}
+	}
+
+	return nil, nil, errNoUnderlayAddress
+}
+
+// underlayAddress returns the first address of the family "ip4" or "ip6"
+// which is assigned to an interface of the node.
+//
+// Addresses of loopback and tunnel interfaces are skipped
+// as tunnels can not be stacked on top of the overlay of other tunnels.
+func (n *BaseNode) underlayAddress(family string) net.IP {
+	for _, i := range n.Interfaces {
+		if i.IsLoopback() || isTunnel(i.Link) {
+			continue
+		}
+
+		for _, a := range i.Addresses {
+			isV4 := len(a.IP.To4()) == net.IPv4len
+
+			if family == "ip4" && isV4 || family == "ip6" && !isV4 {
+				return a.IP
+			}
+		}
+	}
+
+	return nil
+}
+
+func isTunnel(l nl.Link) bool {
+	switch l.(type) {
+	case *nl.Vxlan, *nl.Gretun, *nl.Wireguard:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,166 @@
+// SPDX-FileCopyrightText: 2023 Steffen Vogel <post@steffenvogel.de>
+// SPDX-License-Identifier: Apache-2.0
+
+package gont
+
+import (
+	"errors"
+	"fmt"
+	"net"
+
+	"github.com/gopacket/gopacket/pcapgo"
+	nl "github.com/vishvananda/netlink"
+	"go.uber.org/zap"
+	"golang.zx2c4.com/wireguard/wgctrl"
+	"golang.zx2c4.com/wireguard/wgctrl/wgtypes"
+)
+
+// DefaultWireGuardPort is the UDP port of the first WireGuard device of a node.
+// Further devices of the same node listen on consecutive ports.
+const DefaultWireGuardPort = 51820
+
+type WireGuardOption interface {
+	ApplyWireGuard(cfg *wgtypes.Config)
+}
+
+// AddWireGuard connects the nodes of the interfaces l and r via a
+// point-to-point WireGuard tunnel.
+//
+// Fresh key pairs are generated for both sides of the tunnel.
+// Their static private keys are passed to all captures with enabled key logging.
+func (n *Network) AddWireGuard(l, r *Interface, opts ...Option) error {
+	n.logger.Info("Adding new WireGuard tunnel",
+		zap.Any("left", l),
+		zap.Any("right", r),
+	)
+
+	lAddr, rAddr, err := n.addTunnel(l, r, func(i *Interface, _, _ net.IP) nl.Link {
+		return &nl.Wireguard{
+			LinkAttrs: nl.LinkAttrs{
+				Name: i.Name,
+			},
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	lCfg, err := n.wireGuardConfig(l, opts...)
+	if err != nil {
+		return err
+	}
+
+	rCfg, err := n.wireGuardConfig(r, opts...)
+	if err != nil {
+		return err
+	}
+
+	for _, ep := range []struct {
+		intf         *Interface
+		cfg, peerCfg wgtypes.Config
+		peerAddr     net.IP
+	}{
+		{l, lCfg, rCfg, rAddr},
+		{r, rCfg, lCfg, lAddr},
+	} {
+		ep.cfg.Peers[0].PublicKey = ep.peerCfg.PrivateKey.PublicKey()
+		ep.cfg.Peers[0].Endpoint = &net.UDPAddr{
+			IP:   ep.peerAddr,
+			Port: *ep.peerCfg.ListenPort,
+		}
+
+		if err := configureWireGuardDevice(ep.intf, ep.cfg); err != nil {
+			return fmt.Errorf("failed to configure WireGuard device %s: %w", ep.intf, err)
+		}
+	}
+
+	// Pass static keys to captures with key logging enabled
+	if wr, err := n.KeyLogPipe(pcapgo.DSB_SECRETS_TYPE_WIREGUARD); err == nil {
+		for _, cfg := range []wgtypes.Config{lCfg, rCfg} {
+			if _, err := fmt.Fprintf(wr, "LOCAL_STATIC_PRIVATE_KEY = %s\n", cfg.PrivateKey); err != nil {
+				return fmt.Errorf("failed to write key log: %w", err)
+			}
+		}
+
+		if err := wr.Close(); err != nil {
+			return fmt.Errorf("failed to close key log pipe: %w", err)
+		}
+	} else if !errors.Is(err, errNoKeyLogs) {
+		return fmt.Errorf("failed to open key log pipe: %w", err)
+	}
+
+	return nil
+}
+
+// wireGuardConfig returns a device configuration for the interface i with a freshly generated
+// private key and a single peer which is allowed to send traffic from any address.
+func (n *Network) wireGuardConfig(i *Interface, opts ...Option) (wgtypes.Config, error) {
+	key, err := wgtypes.GeneratePrivateKey()
+	if err != nil {
+		return wgtypes.Config{}, fmt.Errorf("failed to generate private key: %w", err)
+	}
+
+	// Each WireGuard device of a node requires its own port
+	port, err := wireGuardPort(i)
+	if err != nil {
+		return wgtypes.Config{}, err
+	}
+
+	allowedIPs := []net.IPNet{}
+	if !n.IPv4Disabled {
+		allowedIPs = append(allowedIPs, DefaultIPv4Mask)
+	}
+	if !n.IPv6Disabled {
+		allowedIPs = append(allowedIPs, DefaultIPv6Mask)
+	}
+
+	cfg := wgtypes.Config{
+		PrivateKey:   &key,
+		ListenPort:   &port,
+		ReplacePeers: true,
+		Peers: []wgtypes.PeerConfig{
+			{
+				ReplaceAllowedIPs: true,
+				AllowedIPs:        allowedIPs,
+			},
+		},
+	}
+
+	for _, opt := range opts {
+		if opt, ok := opt.(WireGuardOption); ok {
+			opt.ApplyWireGuard(&cfg)
+		}
+	}
+
+	return cfg, nil
+}
+
+// wireGuardPort returns DefaultWireGuardPort plus the
+// number of other WireGuard devices of the node of interface i.
+func wireGuardPort(i *Interface) (int, error) {
+	links, err := i.Node.NetlinkHandle().LinkList()
+	if err != nil {
+		return 0, fmt.Errorf("failed to list links: %w", err)
+	}
+
+	port := DefaultWireGuardPort
+	for _, l := range links {
+		if _, ok := l.(*nl.Wireguard); ok && l.Attrs().Name != i.Name {
+			port++
+		}
+	}
+
+	return port, nil
+}
+
+func configureWireGuardDevice(i *Interface, cfg wgtypes.Config) error {
+	return i.Node.RunFunc(func() error {
+		c, err := wgctrl.New()
+		if err != nil {
+			return fmt.Errorf("failed to create WireGuard client: %w", err)
+		}
+		defer c.Close()
+
+		return c.ConfigureDevice(i.Name, cfg)
+	})
+}
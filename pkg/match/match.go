@@ -0,0 +1,293 @@
+// SPDX-FileCopyrightText: 2023 Steffen Vogel <post@steffenvogel.de>
+// SPDX-License-Identifier: Apache-2.0
+
+// Package match provides composable matchers for captured packets
+// which are used by the expectations of g.Capture.
+//
+//	pkt, err := c.Expect(ctx, match.TCP().From(h1).To(h2).DPort(80).Flags(match.SYN))
+package match
+
+import (
+	"bytes"
+	"net"
+	"slices"
+
+	"cunicu.li/gont/v2/internal/utils"
+	g "cunicu.li/gont/v2/pkg"
+	"github.com/gopacket/gopacket"
+	"github.com/gopacket/gopacket/layers"
+)
+
+var _ g.PacketMatcher = (*Matcher)(nil)
+
+// TCPFlags is a set of TCP header flags.
+type TCPFlags uint8
+
+const (
+	FIN TCPFlags = 1 << iota
+	SYN
+	RST
+	PSH
+	ACK
+	URG
+	ECE
+	CWR
+)
+
+// Condition is a single predicate of a Matcher.
+//
+// The captured packet is nil if the Matcher is used via MatchPacket().
+type Condition func(cp *g.CapturePacket, p gopacket.Packet) bool
+
+// Matcher matches captured packets if all of its conditions are met.
+//
+// Conditions are added by chaining the methods of the Matcher.
+// Each method returns a new Matcher and leaves its receiver unchanged.
+// Invalid arguments panic, similar to the filters.RuleBuilder.
+type Matcher struct {
+	conds []Condition
+}
+
+// Packet returns a new matcher which matches all packets.
+func Packet() *Matcher {
+	return &Matcher{}
+}
+
+// Layer returns a new matcher for packets which contain a layer of the given type.
+func Layer(typ gopacket.LayerType) *Matcher {
+	return Packet().Layer(typ)
+}
+
+func IPv4() *Matcher   { return Layer(layers.LayerTypeIPv4) }
+func IPv6() *Matcher   { return Layer(layers.LayerTypeIPv6) }
+func ARP() *Matcher    { return Layer(layers.LayerTypeARP) }
+func TCP() *Matcher    { return Layer(layers.LayerTypeTCP) }
+func UDP() *Matcher    { return Layer(layers.LayerTypeUDP) }
+func SCTP() *Matcher   { return Layer(layers.LayerTypeSCTP) }
+func ICMPv4() *Matcher { return Layer(layers.LayerTypeICMPv4) }
+func ICMPv6() *Matcher { return Layer(layers.LayerTypeICMPv6) }
+func DNS() *Matcher    { return Layer(layers.LayerTypeDNS) }
+
+// All returns a matcher which matches if all of the given matchers match.
+func All(ms ...g.PacketMatcher) *Matcher {
+	return Packet().Where(func(cp *g.CapturePacket, p gopacket.Packet) bool {
+		for _, m := range ms {
+			if !matchWith(m, cp, p) {
+				return false
+			}
+		}
+
+		return true
+	})
+}
+
+// Any returns a matcher which matches if at least one of the given matchers matches.
+func Any(ms ...g.PacketMatcher) *Matcher {
+	return Packet().Where(func(cp *g.CapturePacket, p gopacket.Packet) bool {
+		for _, m := range ms {
+			if matchWith(m, cp, p) {
+				return true
+			}
+		}
+
+		return false
+	})
+}
+
+// Not returns a matcher which matches if the given matcher does not match.
+func Not(m g.PacketMatcher) *Matcher {
+	return Packet().Where(func(cp *g.CapturePacket, p gopacket.Packet) bool {
+		return !matchWith(m, cp, p)
+	})
+}
+
+// Match implements g.PacketMatcher.
+func (m *Matcher) Match(cp *g.CapturePacket) bool {
+	return m.match(cp, cp.Decode(gopacket.DecodeOptions{
+		Lazy:   true,
+		NoCopy: true,
+	}))
+}
+
+// MatchPacket matches an already decoded packet.
+// Conditions which require capture metadata like Interface() do not match.
+func (m *Matcher) MatchPacket(p gopacket.Packet) bool {
+	return m.match(nil, p)
+}
+
+func (m *Matcher) match(cp *g.CapturePacket, p gopacket.Packet) bool {
+	for _, cond := range m.conds {
+		if !cond(cp, p) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Where returns a copy of the matcher with an additional custom condition.
+//
+// The receiver is left unchanged. Hence, a matcher can be shared
+// as the common base of several other matchers.
+func (m *Matcher) Where(cond Condition) *Matcher {
+	return &Matcher{
+		conds: append(slices.Clip(m.conds), cond),
+	}
+}
+
+// Layer requires a layer of the given type.
+func (m *Matcher) Layer(typ gopacket.LayerType) *Matcher {
+	return m.Where(func(_ *g.CapturePacket, p gopacket.Packet) bool {
+		return p.Layer(typ) != nil
+	})
+}
+
+// From requires the source address to match.
+//
+// The address can be a net.IP, *net.IPNet, a string or a node like *g.Host or *g.Router
+// whose addresses on all interfaces are matched.
+func (m *Matcher) From(addr any) *Matcher {
+	netws := prefixes(addr)
+
+	return m.Where(func(_ *g.CapturePacket, p gopacket.Packet) bool {
+		src, _, ok := networkAddresses(p)
+		return ok && contains(netws, src)
+	})
+}
+
+// To requires the destination address to match.
+// See From() for supported address types.
+func (m *Matcher) To(addr any) *Matcher {
+	netws := prefixes(addr)
+
+	return m.Where(func(_ *g.CapturePacket, p gopacket.Packet) bool {
+		_, dst, ok := networkAddresses(p)
+		return ok && contains(netws, dst)
+	})
+}
+
+// Host requires either the source or destination address to match.
+// See From() for supported address types.
+func (m *Matcher) Host(addr any) *Matcher {
+	netws := prefixes(addr)
+
+	return m.Where(func(_ *g.CapturePacket, p gopacket.Packet) bool {
+		src, dst, ok := networkAddresses(p)
+		return ok && (contains(netws, src) || contains(netws, dst))
+	})
+}
+
+// SPort requires the TCP, UDP or SCTP source port to match.
+func (m *Matcher) SPort(port uint16) *Matcher {
+	return m.Where(func(_ *g.CapturePacket, p gopacket.Packet) bool {
+		sport, _, ok := ports(p)
+		return ok && sport == port
+	})
+}
+
+// DPort requires the TCP, UDP or SCTP destination port to match.
+func (m *Matcher) DPort(port uint16) *Matcher {
+	return m.Where(func(_ *g.CapturePacket, p gopacket.Packet) bool {
+		_, dport, ok := ports(p)
+		return ok && dport == port
+	})
+}
+
+// Port requires either the source or destination port to match.
+func (m *Matcher) Port(port uint16) *Matcher {
+	return m.Where(func(_ *g.CapturePacket, p gopacket.Packet) bool {
+		sport, dport, ok := ports(p)
+		return ok && (sport == port || dport == port)
+	})
+}
+
+// Flags requires all of the given TCP flags to be set.
+func (m *Matcher) Flags(flags TCPFlags) *Matcher {
+	return m.Where(func(_ *g.CapturePacket, p gopacket.Packet) bool {
+		tcp, ok := p.Layer(layers.LayerTypeTCP).(*layers.TCP)
+		return ok && tcpFlags(tcp)&flags == flags
+	})
+}
+
+// Interface requires the packet to be captured on the interface
+// with the given name in the form "node/interface".
+func (m *Matcher) Interface(name string) *Matcher {
+	return m.Where(func(cp *g.CapturePacket, _ gopacket.Packet) bool {
+		return cp != nil && cp.InterfaceName() == name
+	})
+}
+
+// Contains requires the raw packet data to contain the given bytes.
+//
+// This is useful to assert that no plaintext is leaked:
+//
+//	err := c.ExpectNone(5*time.Second, match.Packet().Contains([]byte("secret")))
+func (m *Matcher) Contains(b []byte) *Matcher {
+	return m.Where(func(_ *g.CapturePacket, p gopacket.Packet) bool {
+		return bytes.Contains(p.Data(), b)
+	})
+}
+
+func matchWith(m g.PacketMatcher, cp *g.CapturePacket, p gopacket.Packet) bool {
+	if m, ok := m.(*Matcher); ok {
+		return m.match(cp, p)
+	}
+
+	return cp != nil && m.Match(cp)
+}
+
+func networkAddresses(p gopacket.Packet) (net.IP, net.IP, bool) {
+	switch nl := p.NetworkLayer().(type) {
+	case *layers.IPv4:
+		return nl.SrcIP, nl.DstIP, true
+	case *layers.IPv6:
+		return nl.SrcIP, nl.DstIP, true
+	default:
+		return nil, nil, false
+	}
+}
+
+func ports(p gopacket.Packet) (uint16, uint16, bool) {
+	switch tl := p.TransportLayer().(type) {
+	case *layers.TCP:
+		return uint16(tl.SrcPort), uint16(tl.DstPort), true
+	case *layers.UDP:
+		return uint16(tl.SrcPort), uint16(tl.DstPort), true
+	case *layers.SCTP:
+		return uint16(tl.SrcPort), uint16(tl.DstPort), true
+	default:
+		return 0, 0, false
+	}
+}
+
+func tcpFlags(tcp *layers.TCP) TCPFlags {
+	var f TCPFlags
+
+	for i, set := range []bool{tcp.FIN, tcp.SYN, tcp.RST, tcp.PSH, tcp.ACK, tcp.URG, tcp.ECE, tcp.CWR} {
+		if set {
+			f |= 1 << i
+		}
+	}
+
+	return f
+}
+
+func contains(netws []*net.IPNet, ip net.IP) bool {
+	for _, netw := range netws {
+		if netw.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// prefixes converts an address into the prefixes which are matched.
+func prefixes(addr any) []*net.IPNet {
+	netws, err := utils.Prefixes(addr, "ip")
+	if err != nil {
+		panic(err)
+	}
+
+	return netws
+}
@@ -0,0 +1,123 @@
+// SPDX-FileCopyrightText: 2023 Steffen Vogel <post@steffenvogel.de>
+// SPDX-License-Identifier: Apache-2.0
+
+package match_test
+
+import (
+	"net"
+	"testing"
+
+	g "cunicu.li/gont/v2/pkg"
+	"cunicu.li/gont/v2/pkg/match"
+	"github.com/gopacket/gopacket"
+	"github.com/gopacket/gopacket/layers"
+	"github.com/stretchr/testify/require"
+)
+
+func tcpPacket(t *testing.T, src, dst string, sport, dport uint16, syn, ack bool, payload []byte) gopacket.Packet {
+	ip := &layers.IPv4{
+		Version:  4,
+		TTL:      64,
+		Protocol: layers.IPProtocolTCP,
+		SrcIP:    net.ParseIP(src),
+		DstIP:    net.ParseIP(dst),
+	}
+
+	tcp := &layers.TCP{
+		SrcPort: layers.TCPPort(sport),
+		DstPort: layers.TCPPort(dport),
+		SYN:     syn,
+		ACK:     ack,
+	}
+
+	err := tcp.SetNetworkLayerForChecksum(ip)
+	require.NoError(t, err)
+
+	buf := gopacket.NewSerializeBuffer()
+	err = gopacket.SerializeLayers(buf, gopacket.SerializeOptions{
+		FixLengths:       true,
+		ComputeChecksums: true,
+	}, ip, tcp, gopacket.Payload(payload))
+	require.NoError(t, err, "Failed to serialize packet")
+
+	return gopacket.NewPacket(buf.Bytes(), layers.LayerTypeIPv4, gopacket.Default)
+}
+
+func TestMatcher(t *testing.T) {
+	syn := tcpPacket(t, "10.0.0.1", "10.0.0.2", 12345, 80, true, false, nil)
+	data := tcpPacket(t, "10.0.0.2", "10.0.0.1", 80, 12345, false, true, []byte("secret"))
+
+	for _, tc := range []struct {
+		name    string
+		matcher *match.Matcher
+		syn     bool
+		data    bool
+	}{
+		{"tcp", match.TCP(), true, true},
+		{"udp", match.UDP(), false, false},
+		{"from", match.TCP().From("10.0.0.1"), true, false},
+		{"to prefix", match.IPv4().To("10.0.0.0/24"), true, true},
+		{"dport", match.TCP().DPort(80), true, false},
+		{"port", match.TCP().Port(80), true, true},
+		{"flags", match.TCP().Flags(match.SYN), true, false},
+		{"flags all", match.TCP().Flags(match.SYN | match.ACK), false, false},
+		{"contains", match.Packet().Contains([]byte("secret")), false, true},
+		{"not", match.Not(match.TCP().Flags(match.SYN)), false, true},
+		{"any", match.Any(match.TCP().SPort(12345), match.UDP()), true, false},
+		{"all", match.All(match.TCP().SPort(80), match.Packet().Contains([]byte("secret"))), false, true},
+		{"interface", match.TCP().Interface("h1/veth0"), false, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.syn, tc.matcher.MatchPacket(syn), "Unexpected result for SYN packet")
+			require.Equal(t, tc.data, tc.matcher.MatchPacket(data), "Unexpected result for data packet")
+		})
+	}
+}
+
+func TestMatcherShared(t *testing.T) {
+	syn := tcpPacket(t, "10.0.0.1", "10.0.0.2", 12345, 80, true, false, nil)
+
+	base := match.TCP()
+	http := base.DPort(80)
+	https := base.DPort(443)
+
+	require.True(t, base.MatchPacket(syn))
+	require.True(t, http.MatchPacket(syn))
+	require.False(t, https.MatchPacket(syn))
+}
+
+func TestMatcherInvalidAddress(t *testing.T) {
+	require.Panics(t, func() { match.TCP().From("not-an-address") })
+	require.Panics(t, func() { match.TCP().To(42) })
+}
+
+func TestMatcherRouter(t *testing.T) {
+	r := &g.Router{
+		Host: &g.Host{
+			BaseNode: &g.BaseNode{
+				Interfaces: []*g.Interface{
+					{
+						Name: "veth0",
+						Addresses: []net.IPNet{
+							{IP: net.ParseIP("10.0.1.1"), Mask: net.CIDRMask(24, 32)},
+						},
+					},
+					{
+						Name: "veth1",
+						Addresses: []net.IPNet{
+							{IP: net.ParseIP("10.0.0.2"), Mask: net.CIDRMask(24, 32)},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	syn := tcpPacket(t, "10.0.0.1", "10.0.0.2", 12345, 80, true, false, nil)
+	data := tcpPacket(t, "10.0.0.2", "10.0.0.1", 80, 12345, false, true, []byte("secret"))
+
+	// All addresses of the router are matched, not only the first one
+	require.True(t, match.TCP().To(r).MatchPacket(syn))
+	require.False(t, match.TCP().To(r).MatchPacket(data))
+	require.True(t, match.TCP().Host(r).MatchPacket(data))
+}
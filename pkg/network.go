@@ -19,7 +19,10 @@ import (
 	"go.uber.org/zap"
 )
 
-var errNoKeyLogs = errors.New("no captures with keylogs")
+var (
+	errNoKeyLogs         = errors.New("no captures with keylogs")
+	errRoutingTableNamed = errors.New("routing table already has a different name")
+)
 
 type NetworkOption interface {
 	ApplyNetwork(n *Network)
@@ -35,7 +38,8 @@ type Network struct {
 	nodes     map[string]Node
 	nodesLock sync.RWMutex
 
-	hostsFileLock sync.Mutex
+	hostsFileLock     sync.Mutex
+	iproute2FilesLock sync.Mutex
 
 	// Options
 	Captures      []*Capture
@@ -44,6 +48,7 @@ type Network struct {
 	IPv6Disabled  bool
 	Persistent    bool
 	RedirectToLog bool
+	RoutingTables map[uint32]string
 	Slice         string
 	Tracer        *Tracer
 
@@ -72,12 +77,13 @@ func NewNetwork(name string, opts ...Option) (n *Network, err error) {
 	tmpPath := filepath.Join(baseTmpDir, name)
 
 	n = &Network{
-		Name:    name,
-		VarPath: varPath,
-		TmpPath: tmpPath,
-		Slice:   fmt.Sprintf("gont-%s", name),
-		nodes:   map[string]Node{},
-		logger:  zap.L().Named("network").With(zap.String("network", name)),
+		Name:          name,
+		VarPath:       varPath,
+		TmpPath:       tmpPath,
+		Slice:         fmt.Sprintf("gont-%s", name),
+		nodes:         map[string]Node{},
+		RoutingTables: map[uint32]string{},
+		logger:        zap.L().Named("network").With(zap.String("network", name)),
 	}
 
 	// Apply network specific options
@@ -99,6 +105,13 @@ func NewNetwork(name string, opts ...Option) (n *Network, err error) {
 		}
 	}
 
+	// Expose trace events for gontc
+	if t := n.Tracer; t != nil && t.Expose {
+		if err := t.expose(filepath.Join(varPath, traceSocketName)); err != nil {
+			return nil, fmt.Errorf("failed to expose tracer: %w", err)
+		}
+	}
+
 	// Setup CGroup slice
 	if n.CGroup, err = NewCGroup(nil, "slice", n.Slice, opts...); err != nil {
 		return nil, fmt.Errorf("failed to create cgroup: %w", err)
@@ -172,6 +185,21 @@ func (n *Network) Switches() []*Switch {
 	return switches
 }
 
+func (n *Network) OVSSwitches() []*OVSSwitch {
+	n.nodesLock.RLock()
+	defer n.nodesLock.RUnlock()
+
+	switches := []*OVSSwitch{}
+
+	for _, node := range n.nodes {
+		if sw, ok := node.(*OVSSwitch); ok {
+			switches = append(switches, sw)
+		}
+	}
+
+	return switches
+}
+
 func (n *Network) Routers() []*Router {
 	n.nodesLock.RLock()
 	defer n.nodesLock.RUnlock()
@@ -281,6 +309,24 @@ func (n *Network) Register(m Node) {
 	n.nodes[m.Name()] = m
 }
 
+// AddRoutingTable assigns a name to the routing table with the given ID.
+// Named tables are shown by iproute2's ip command within the nodes of the network.
+// The names are shared by all nodes unless a node names the table itself
+// via BaseNode.AddRoutingTable(). Hence, each ID can only have a single network-wide name.
+func (n *Network) AddRoutingTable(id uint32, name string) error {
+	n.iproute2FilesLock.Lock()
+
+	if existing, ok := n.RoutingTables[id]; ok && existing != name {
+		n.iproute2FilesLock.Unlock()
+		return fmt.Errorf("%w: table %d is named '%s'", errRoutingTableNamed, id, existing)
+	}
+
+	n.RoutingTables[id] = name
+	n.iproute2FilesLock.Unlock()
+
+	return n.generateIProute2Files()
+}
+
 func (n *Network) KeyLogPipe(secretsType uint32) (*os.File, error) {
 	capturesWithKeys := []*Capture{}
 	for _, c := range n.Captures {
@@ -250,16 +250,83 @@ func (n *Network) patchNSSConfFile() error {
 }
 
 func (n *Network) generateIProute2Files() error {
-	contentsOrig, err := os.ReadFile("/etc/iproute2/group")
+	n.iproute2FilesLock.Lock()
+	defer n.iproute2FilesLock.Unlock()
+
+	// Add Gont specific groups
+	groups := map[uint32]string{
+		uint32(DeviceGroupNorthBound): "north-bound",
+		uint32(DeviceGroupSouthBound): "south-bound",
+	}
+
+	dir := filepath.Join(n.VarPath, "files/etc/iproute2")
+
+	if err := patchIProute2File(dir, "group", groups); err != nil {
+		return err
+	}
+
+	if err := patchIProute2File(dir, "rt_tables", n.RoutingTables); err != nil {
+		return err
+	}
+
+	// Nodes with their own routing table names need to pick up
+	// changes of the network-wide names as well.
+	for _, node := range n.Nodes() {
+		if rtn, ok := node.(routingTablesFileGenerator); ok {
+			if err := rtn.generateRoutingTablesFile(); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+type routingTablesFileGenerator interface {
+	generateRoutingTablesFile() error
+}
+
+// generateRoutingTablesFile writes the routing table names of the network
+// and the node to /run/gont/<network>/nodes/<node>/files/etc/iproute2/rt_tables
+// which overrides the network-wide file for processes of the node.
+//
+// The caller must hold the network's iproute2FilesLock.
+func (n *BaseNode) generateRoutingTablesFile() error {
+	dir := filepath.Join(n.VarPath, "files/etc/iproute2")
+
+	if len(n.RoutingTables) == 0 {
+		if err := os.Remove(filepath.Join(dir, "rt_tables")); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+
+		return nil
+	}
+
+	entries := map[uint32]string{}
+	for id, name := range n.network.RoutingTables {
+		entries[id] = name
+	}
+	for id, name := range n.RoutingTables {
+		entries[id] = name
+	}
+
+	return patchIProute2File(dir, "rt_tables", entries)
+}
+
+// patchIProute2File appends the given ID to name mappings to the
+// iproute2 configuration file /etc/iproute2/<name> and writes the
+// result to <dir>/<name>
+func patchIProute2File(dir, name string, entries map[uint32]string) error {
+	contentsOrig, err := os.ReadFile(filepath.Join("/etc/iproute2", name))
 	if err != nil {
 		if errors.Is(err, os.ErrNotExist) {
 			return nil // We do not throw an error as there is no file to patch
 		}
 
-		return fmt.Errorf("failed to read /etc/iproute2/group file: %w", err)
+		return fmt.Errorf("failed to read /etc/iproute2/%s file: %w", name, err)
 	}
 
-	fn := filepath.Join(n.VarPath, "files/etc/iproute2/group")
+	fn := filepath.Join(dir, name)
 	if err := os.MkdirAll(filepath.Dir(fn), 0o755); err != nil {
 		return err
 	}
@@ -280,14 +347,10 @@ func (n *Network) generateIProute2Files() error {
 		return err
 	}
 
-	// Add Gont specific groups
-	groups := map[DeviceGroup]string{
-		DeviceGroupNorthBound: "north-bound",
-		DeviceGroupSouthBound: "south-bound",
-	}
-
-	for group, name := range groups {
-		fmt.Fprintf(f, "%d %s\n", group, name)
+	for id, name := range entries {
+		if _, err := fmt.Fprintf(f, "%d %s\n", id, name); err != nil {
+			return err
+		}
 	}
 
 	return nil
@@ -0,0 +1,80 @@
+// SPDX-FileCopyrightText: 2023 Steffen Vogel <post@steffenvogel.de>
+// SPDX-License-Identifier: Apache-2.0
+
+package options
+
+import (
+	"time"
+
+	g "cunicu.li/gont/v2/pkg"
+	nl "github.com/vishvananda/netlink"
+)
+
+// BondMode sets the mode of a bond interface.
+type BondMode nl.BondMode
+
+const (
+	BondModeBalanceRR    = BondMode(nl.BOND_MODE_BALANCE_RR)
+	BondModeActiveBackup = BondMode(nl.BOND_MODE_ACTIVE_BACKUP)
+	BondModeBalanceXOR   = BondMode(nl.BOND_MODE_BALANCE_XOR)
+	BondModeBroadcast    = BondMode(nl.BOND_MODE_BROADCAST)
+	BondMode8023AD       = BondMode(nl.BOND_MODE_802_3AD)
+	BondModeBalanceTLB   = BondMode(nl.BOND_MODE_BALANCE_TLB)
+	BondModeBalanceALB   = BondMode(nl.BOND_MODE_BALANCE_ALB)
+)
+
+func (m BondMode) ApplyBond(b *nl.Bond) {
+	b.Mode = nl.BondMode(m)
+}
+
+// BondMIIMonitor sets the interval in which the link state of the bond members is checked.
+type BondMIIMonitor time.Duration
+
+func (m BondMIIMonitor) ApplyBond(b *nl.Bond) {
+	b.Miimon = int(time.Duration(m).Milliseconds())
+}
+
+// BondLACPRateFast requests the 802.3ad link partner to transmit LACPDUs every second instead of every 30 seconds.
+type BondLACPRateFast bool
+
+func (f BondLACPRateFast) ApplyBond(b *nl.Bond) {
+	if f {
+		b.LacpRate = nl.BOND_LACP_RATE_FAST
+	} else {
+		b.LacpRate = nl.BOND_LACP_RATE_SLOW
+	}
+}
+
+// Bonding turns an interface into a bond over its member interfaces.
+type Bonding struct {
+	Options []g.BondOption
+	Members []*g.Interface
+}
+
+func (b Bonding) ApplyInterface(i *g.Interface) {
+	if i.Bond == nil {
+		i.Bond = nl.NewLinkBond(nl.LinkAttrs{})
+	}
+
+	for _, opt := range b.Options {
+		opt.ApplyBond(i.Bond)
+	}
+
+	i.BondMembers = append(i.BondMembers, b.Members...)
+}
+
+// Bond turns an interface into a bond over the member interfaces using the given mode.
+func Bond(mode BondMode, members ...*g.Interface) Bonding {
+	return Bonding{
+		Options: []g.BondOption{mode},
+		Members: members,
+	}
+}
+
+// BondWithOptions turns an interface into a bond over the member interfaces with additional bond options.
+func BondWithOptions(opts []g.BondOption, members ...*g.Interface) Bonding {
+	return Bonding{
+		Options: opts,
+		Members: members,
+	}
+}
@@ -6,6 +6,7 @@ package options
 import (
 	"time"
 
+	g "cunicu.li/gont/v2/pkg"
 	nl "github.com/vishvananda/netlink"
 )
 
@@ -45,3 +46,94 @@ func (ht HelloTime) ApplyBridge(b *nl.Bridge) {
 	v := uint32(htt.Seconds())
 	b.HelloTime = &v
 }
+
+// STP enables the IEEE 802.1D spanning tree protocol.
+type STP bool
+
+func (stp STP) ApplySwitch(sw *g.Switch) {
+	sw.SpanningTree.Enabled = bool(stp)
+}
+
+// RSTP enables the rapid spanning tree protocol.
+// This requires a user-space STP daemon like mstpd which is installed as /sbin/bridge-stp.
+type RSTP bool
+
+func (rstp RSTP) ApplySwitch(sw *g.Switch) {
+	sw.SpanningTree.Enabled = bool(rstp)
+	sw.SpanningTree.Rapid = bool(rstp)
+}
+
+// BridgePriority sets the bridge priority used for the election of the STP root bridge.
+// Lower values are preferred.
+type BridgePriority uint16
+
+func (p BridgePriority) ApplySwitch(sw *g.Switch) {
+	v := uint16(p)
+	sw.SpanningTree.Priority = &v
+}
+
+// ForwardDelay sets the time a port spends in the listening and learning states.
+// Only relevant if STP is enabled. Valid values are between 2 and 30 seconds.
+type ForwardDelay time.Duration
+
+func (fd ForwardDelay) ApplySwitch(sw *g.Switch) {
+	v := time.Duration(fd)
+	sw.SpanningTree.ForwardDelay = &v
+}
+
+// MaxAge sets the time after which received STP information is discarded.
+// Only relevant if STP is enabled. Valid values are between 6 and 40 seconds.
+type MaxAge time.Duration
+
+func (ma MaxAge) ApplySwitch(sw *g.Switch) {
+	v := time.Duration(ma)
+	sw.SpanningTree.MaxAge = &v
+}
+
+// PortCost sets the STP path cost of a bridge port.
+type PortCost uint32
+
+func (pc PortCost) ApplyInterface(i *g.Interface) {
+	v := uint32(pc)
+	i.BridgePort.Cost = &v
+}
+
+// PortPriority sets the STP priority of a bridge port.
+type PortPriority uint16
+
+func (pp PortPriority) ApplyInterface(i *g.Interface) {
+	v := uint16(pp)
+	i.BridgePort.Priority = &v
+}
+
+// PortLearning enables learning of source MAC addresses on a bridge port.
+type PortLearning bool
+
+func (pl PortLearning) ApplyInterface(i *g.Interface) {
+	v := bool(pl)
+	i.BridgePort.Learning = &v
+}
+
+// PortUnicastFlood enables flooding of unicast frames with an unknown destination to a bridge port.
+type PortUnicastFlood bool
+
+func (pf PortUnicastFlood) ApplyInterface(i *g.Interface) {
+	v := bool(pf)
+	i.BridgePort.UnicastFlood = &v
+}
+
+// PortMulticastFlood enables flooding of multicast frames to a bridge port.
+type PortMulticastFlood bool
+
+func (pf PortMulticastFlood) ApplyInterface(i *g.Interface) {
+	v := bool(pf)
+	i.BridgePort.MulticastFlood = &v
+}
+
+// PortBroadcastFlood enables flooding of broadcast frames to a bridge port.
+type PortBroadcastFlood bool
+
+func (pf PortBroadcastFlood) ApplyInterface(i *g.Interface) {
+	v := bool(pf)
+	i.BridgePort.BroadcastFlood = &v
+}
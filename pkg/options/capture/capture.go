@@ -6,6 +6,7 @@ package capture
 
 import (
 	"os"
+	"time"
 
 	g "cunicu.li/gont/v2/pkg"
 	"golang.org/x/net/bpf"
@@ -121,6 +122,9 @@ func ToFile(f *os.File) File { return File{f} }
 // Filename writes all captured packets in PCAPng format to a new or existing file
 // with the provided filename.
 // Any existing files will be truncated
+//
+// The filename is a Go template which can include the fields {{ .Network }},
+// {{ .Node }}, {{ .Interface }} as well as {{ .Index }} and {{ .Time }} for rotated files.
 type Filename string
 
 func (fn Filename) ApplyCapture(c *g.Capture) {
@@ -129,6 +133,120 @@ func (fn Filename) ApplyCapture(c *g.Capture) {
 
 func ToFilename(fn string) Filename { return Filename(fn) }
 
+// SplitByInterface writes the packets of each interface into a separate file.
+//
+// The filenames are rendered from the templates passed via Filename() for each interface.
+// Hence, they must include at least the {{ .Node }} and {{ .Interface }} fields.
+// Otherwise, adding the interfaces to the capture fails.
+// Files, pipes and listeners are not split and still receive all packets.
+type SplitByInterface bool
+
+func (s SplitByInterface) ApplyCapture(c *g.Capture) {
+	c.SplitByInterface = bool(s)
+}
+
+// SplitByNode writes the packets of all interfaces of a node into a separate file.
+//
+// The filenames are rendered from the templates passed via Filename() for each node.
+// Hence, they must include at least the {{ .Node }} field.
+// Otherwise, adding the interfaces to the capture fails.
+type SplitByNode bool
+
+func (s SplitByNode) ApplyCapture(c *g.Capture) {
+	c.SplitByNode = bool(s)
+}
+
+// DuplicateTrace includes the trace interface into each of the split files
+// rather than writing trace events into a separate file.
+type DuplicateTrace bool
+
+func (d DuplicateTrace) ApplyCapture(c *g.Capture) {
+	c.DuplicateTrace = bool(d)
+}
+
+// RotateSize starts a new file once the current file exceeds the given size in bytes.
+//
+// The size includes packets which are still buffered.
+// As their compression is not known yet, compressed files are rotated slightly early.
+//
+// Rotation applies to files created via Filename() only.
+// The filename template must include the {{ .Index }} or {{ .Time }} fields.
+type RotateSize int64
+
+func (rs RotateSize) ApplyCapture(c *g.Capture) {
+	c.RotateSize = int64(rs)
+}
+
+// RotateDuration starts a new file once the current file has been written for the given duration.
+//
+// Rotation applies to files created via Filename() only.
+// The filename template must include the {{ .Index }} or {{ .Time }} fields.
+type RotateDuration time.Duration
+
+func (rd RotateDuration) ApplyCapture(c *g.Capture) {
+	c.RotateDuration = time.Duration(rd)
+}
+
+// RotateFiles limits the number of rotated files which are kept as a ring buffer.
+// The oldest files get removed once the limit is exceeded.
+type RotateFiles int
+
+func (rf RotateFiles) ApplyCapture(c *g.Capture) {
+	c.RotateFiles = int(rf)
+}
+
+// ReorderWindow holds back captured packets for the given duration
+// in order to write packets of multiple interfaces ordered by their timestamps.
+//
+// Defaults to 1 second. Smaller windows reduce the latency for live viewers
+// at the cost of possibly out-of-order packets.
+type ReorderWindow time.Duration
+
+func (rw ReorderWindow) ApplyCapture(c *g.Capture) {
+	c.ReorderWindow = time.Duration(rw)
+}
+
+// MaxQueuedPackets limits the number of packets held in the reordering queue.
+// Packets are dropped according to the DropPolicy once the limit is reached.
+type MaxQueuedPackets int
+
+func (mp MaxQueuedPackets) ApplyCapture(c *g.Capture) {
+	c.MaxQueuedPackets = int(mp)
+}
+
+// MaxQueuedBytes limits the number of bytes held in the reordering queue.
+// Packets are dropped according to the DropPolicy once the limit is reached.
+type MaxQueuedBytes int
+
+func (mb MaxQueuedBytes) ApplyCapture(c *g.Capture) {
+	c.MaxQueuedBytes = int(mb)
+}
+
+// DropPolicy decides which packets are dropped once the reordering queue is full.
+//
+// Dropped packets are counted in Capture.Stats() and the interface statistics of PCAPng files.
+type DropPolicy g.CaptureDropPolicy
+
+func (dp DropPolicy) ApplyCapture(c *g.Capture) {
+	c.DropPolicy = g.CaptureDropPolicy(dp)
+}
+
+const (
+	DropNewest  = DropPolicy(g.CaptureDropNewest)
+	DropOldest  = DropPolicy(g.CaptureDropOldest)
+	WriteOldest = DropPolicy(g.CaptureWriteOldest)
+)
+
+// Flows aggregates the captured packets into flows which are returned by Capture.Flows().
+//
+// Flow tracking decodes each packet and keeps a table entry per flow
+// for the lifetime of the capture. Hence it is disabled by default.
+type Flows bool
+
+func (f Flows) ApplyCapture(c *g.Capture) {
+	c.TrackFlows = bool(f)
+}
+
 // Pipename writes all captured packets in PCAPng format to a newly created
 // named pipe.
 //
@@ -153,6 +271,11 @@ func ToPipename(pn string) Pipename { return Pipename(pn) }
 // You can use WireShark to connect to this socket to stream captured packets
 // in real-time to a local/remote machine.
 //
+// Multiple viewers can connect and disconnect at any time.
+// Each viewer receives the section header and interface descriptions
+// upon connection followed by all packets captured from then on.
+// Viewers which do not keep up with the captured packets are disconnected.
+//
 // See: https://wiki.wireshark.org/CaptureSetup/Pipes.md#tcp-socket
 type ListenAddr string
 
@@ -163,6 +286,14 @@ func (s ListenAddr) ApplyCapture(c *g.Capture) {
 	c.FlushEach = 1
 }
 
+// WaitForViewer blocks the start of the capture until a viewer
+// has connected to each of the listeners opened via ListenAddr.
+type WaitForViewer bool
+
+func (w WaitForViewer) ApplyCapture(c *g.Capture) {
+	c.WaitForViewer = bool(w)
+}
+
 // Channel sends all captured packets to the provided channel.
 type Channel chan g.CapturePacket
 
@@ -4,7 +4,10 @@
 // Package filters contains the options for configuring NFTables filtering
 package filters
 
-import "github.com/google/nftables/expr"
+import (
+	"github.com/google/nftables/expr"
+	"golang.org/x/sys/unix"
+)
 
 // Statement is a list of one or more nftables expressions
 type Statement []expr.Any
@@ -15,3 +18,16 @@ var Drop = Statement{ //nolint:gochecknoglobals
 		Kind: expr.VerdictDrop,
 	},
 }
+
+// NFLog is a statement which logs packets to an NFLOG group with the given prefix.
+// Packets logged to g.FilterLogGroup are reported to the tracer and captures of hosts with the
+// FilterLog option.
+func NFLog(group uint16, prefix string) Statement {
+	return Statement{
+		&expr.Log{
+			Key:   1<<unix.NFTA_LOG_GROUP | 1<<unix.NFTA_LOG_PREFIX,
+			Group: group,
+			Data:  []byte(prefix),
+		},
+	}
+}
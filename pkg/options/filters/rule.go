@@ -0,0 +1,467 @@
+// SPDX-FileCopyrightText: 2023 Steffen Vogel <post@steffenvogel.de>
+// SPDX-License-Identifier: Apache-2.0
+
+package filters
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"cunicu.li/gont/v2/internal/utils"
+	g "cunicu.li/gont/v2/pkg"
+	"github.com/google/nftables/binaryutil"
+	"github.com/google/nftables/expr"
+	"golang.org/x/sys/unix"
+)
+
+// Transport protocols
+const (
+	TCP    = unix.IPPROTO_TCP
+	UDP    = unix.IPPROTO_UDP
+	SCTP   = unix.IPPROTO_SCTP
+	ICMP   = unix.IPPROTO_ICMP
+	ICMPv6 = unix.IPPROTO_ICMPV6
+)
+
+// CTState is a connection tracking state
+type CTState uint32
+
+const (
+	CTInvalid     = CTState(expr.CtStateBitINVALID)
+	CTEstablished = CTState(expr.CtStateBitESTABLISHED)
+	CTRelated     = CTState(expr.CtStateBitRELATED)
+	CTNew         = CTState(expr.CtStateBitNEW)
+	CTUntracked   = CTState(expr.CtStateBitUNTRACKED)
+)
+
+// RejectCode is the ICMP error which is sent when rejecting a packet
+type RejectCode uint8
+
+const (
+	RejectNoRoute         RejectCode = unix.NFT_REJECT_ICMPX_NO_ROUTE
+	RejectPortUnreachable RejectCode = unix.NFT_REJECT_ICMPX_PORT_UNREACH
+	RejectHostUnreachable RejectCode = unix.NFT_REJECT_ICMPX_HOST_UNREACH
+	RejectAdminProhibited RejectCode = unix.NFT_REJECT_ICMPX_ADMIN_PROHIBITED
+)
+
+// RuleBuilder composes a g.FilterRule from matches, statements and a final verdict.
+//
+// Matches for the network and transport protocol are inferred from the
+// addresses and ports and are always placed at the beginning of the rule.
+// Invalid combinations panic, similar to SourceIP() and DestinationIP().
+type RuleBuilder struct {
+	hook   g.FilterHook
+	family int
+	proto  int
+
+	matches    []Statement
+	statements []Statement
+}
+
+// Rule starts a new filter rule for the input hook.
+func Rule() *RuleBuilder {
+	return &RuleBuilder{
+		hook:  g.FilterInput,
+		proto: -1,
+	}
+}
+
+// Hook selects the chain to which the rule is added.
+func (b *RuleBuilder) Hook(h g.FilterHook) *RuleBuilder {
+	b.hook = h
+	return b
+}
+
+func (b *RuleBuilder) Input() *RuleBuilder   { return b.Hook(g.FilterInput) }
+func (b *RuleBuilder) Output() *RuleBuilder  { return b.Hook(g.FilterOutput) }
+func (b *RuleBuilder) Forward() *RuleBuilder { return b.Hook(g.FilterForward) }
+
+// IPv4 restricts the rule to IPv4 packets.
+func (b *RuleBuilder) IPv4() *RuleBuilder {
+	b.setFamily(unix.AF_INET)
+	return b
+}
+
+// IPv6 restricts the rule to IPv6 packets.
+func (b *RuleBuilder) IPv6() *RuleBuilder {
+	b.setFamily(unix.AF_INET6)
+	return b
+}
+
+// From matches the source address of packets.
+//
+// The address can be given as *net.IPNet, net.IP, a string in CIDR or plain IP notation,
+// or a node like *g.Host or *g.Router whose addresses of the rule's address family are matched.
+// Without an address family, the family of the node's first address is used.
+func (b *RuleBuilder) From(addr any) *RuleBuilder {
+	b.matches = append(b.matches, b.network(dirSource, addr))
+	return b
+}
+
+// To matches the destination address of packets.
+// See From() for the supported address types.
+func (b *RuleBuilder) To(addr any) *RuleBuilder {
+	b.matches = append(b.matches, b.network(dirDestination, addr))
+	return b
+}
+
+// Proto matches the transport protocol, e.g. TCP, UDP or ICMP.
+func (b *RuleBuilder) Proto(proto int) *RuleBuilder {
+	if b.proto >= 0 && b.proto != proto {
+		panic(fmt.Errorf("conflicting transport protocols: %d and %d", b.proto, proto))
+	}
+
+	b.proto = proto
+
+	switch proto {
+	case ICMP:
+		b.setFamily(unix.AF_INET)
+	case ICMPv6:
+		b.setFamily(unix.AF_INET6)
+	}
+
+	return b
+}
+
+func (b *RuleBuilder) SPort(p uint16) *RuleBuilder {
+	b.requirePorts()
+	b.matches = append(b.matches, SourcePort(p))
+	return b
+}
+
+func (b *RuleBuilder) DPort(p uint16) *RuleBuilder {
+	b.requirePorts()
+	b.matches = append(b.matches, DestinationPort(p))
+	return b
+}
+
+func (b *RuleBuilder) SPortRange(minPort, maxPort uint16) *RuleBuilder {
+	b.requirePorts()
+	b.matches = append(b.matches, SourcePortRange(minPort, maxPort))
+	return b
+}
+
+func (b *RuleBuilder) DPortRange(minPort, maxPort uint16) *RuleBuilder {
+	b.requirePorts()
+	b.matches = append(b.matches, DestinationPortRange(minPort, maxPort))
+	return b
+}
+
+// ICMPType matches the type of ICMP or ICMPv6 messages.
+// The protocol defaults to ICMP unless ICMPv6 or IPv6 has been selected before.
+func (b *RuleBuilder) ICMPType(typ uint8) *RuleBuilder {
+	if b.proto < 0 {
+		if b.family == unix.AF_INET6 {
+			b.Proto(ICMPv6)
+		} else {
+			b.Proto(ICMP)
+		}
+	} else if b.proto != ICMP && b.proto != ICMPv6 {
+		panic(fmt.Errorf("ICMP type requires ICMP protocol, got %d", b.proto))
+	}
+
+	b.matches = append(b.matches, Statement{
+		&expr.Payload{
+			DestRegister: 1,
+			Base:         expr.PayloadBaseTransportHeader,
+			Offset:       0,
+			Len:          1,
+		},
+		&expr.Cmp{
+			Op:       expr.CmpOpEq,
+			Register: 1,
+			Data:     []byte{typ},
+		},
+	})
+
+	return b
+}
+
+// CTState matches packets whose connection is in any of the given states.
+func (b *RuleBuilder) CTState(states ...CTState) *RuleBuilder {
+	mask := uint32(0)
+	for _, s := range states {
+		mask |= uint32(s)
+	}
+
+	b.matches = append(b.matches, Statement{
+		&expr.Ct{
+			Key:      expr.CtKeySTATE,
+			Register: 1,
+		},
+		&expr.Bitwise{
+			SourceRegister: 1,
+			DestRegister:   1,
+			Len:            4,
+			Mask:           binaryutil.NativeEndian.PutUint32(mask),
+			Xor:            binaryutil.NativeEndian.PutUint32(0),
+		},
+		&expr.Cmp{
+			Op:       expr.CmpOpNeq,
+			Register: 1,
+			Data:     binaryutil.NativeEndian.PutUint32(0),
+		},
+	})
+
+	return b
+}
+
+// InSet matches packets whose field is contained in a named set.
+// See g.Filter.AddSet().
+func (b *RuleBuilder) InSet(name string, key SetKey) *RuleBuilder {
+	return b.lookup(name, key, false)
+}
+
+// NotInSet matches packets whose field is not contained in a named set.
+func (b *RuleBuilder) NotInSet(name string, key SetKey) *RuleBuilder {
+	return b.lookup(name, key, true)
+}
+
+func (b *RuleBuilder) lookup(name string, key SetKey, invert bool) *RuleBuilder {
+	if f := key.family(); f != 0 {
+		b.setFamily(f)
+	} else {
+		b.requirePorts()
+	}
+
+	b.matches = append(b.matches, lookup(name, key, invert))
+
+	return b
+}
+
+// InputInterface matches the name of the interface on which a packet has been received.
+func (b *RuleBuilder) InputInterface(name string) *RuleBuilder {
+	b.matches = append(b.matches, InputInterfaceName(name))
+	return b
+}
+
+// OutputInterface matches the name of the interface on which a packet will be sent.
+func (b *RuleBuilder) OutputInterface(name string) *RuleBuilder {
+	b.matches = append(b.matches, OutputInterfaceName(name))
+	return b
+}
+
+// Match appends arbitrary statements like the ones provided by this package.
+func (b *RuleBuilder) Match(stmts ...Statement) *RuleBuilder {
+	b.matches = append(b.matches, stmts...)
+	return b
+}
+
+// Limit only matches packets up to the given rate, e.g. 10 packets per time.Second.
+// Supported periods are a second, minute, hour, day and week.
+func (b *RuleBuilder) Limit(rate uint64, per time.Duration) *RuleBuilder {
+	var unit expr.LimitTime
+
+	switch per {
+	case time.Second:
+		unit = expr.LimitTimeSecond
+	case time.Minute:
+		unit = expr.LimitTimeMinute
+	case time.Hour:
+		unit = expr.LimitTimeHour
+	case 24 * time.Hour:
+		unit = expr.LimitTimeDay
+	case 7 * 24 * time.Hour:
+		unit = expr.LimitTimeWeek
+	default:
+		panic(fmt.Errorf("unsupported limit period: %s", per))
+	}
+
+	b.statements = append(b.statements, Statement{
+		&expr.Limit{
+			Type: expr.LimitTypePkts,
+			Rate: rate,
+			Unit: unit,
+		},
+	})
+
+	return b
+}
+
+// Counter counts the packets and bytes matching the rule.
+func (b *RuleBuilder) Counter() *RuleBuilder {
+	b.statements = append(b.statements, Statement{
+		&expr.Counter{},
+	})
+
+	return b
+}
+
+// Log logs matching packets to the kernel log with the given prefix.
+func (b *RuleBuilder) Log(prefix string) *RuleBuilder {
+	b.statements = append(b.statements, Statement{
+		&expr.Log{
+			Key:  1 << unix.NFTA_LOG_PREFIX,
+			Data: []byte(prefix),
+		},
+	})
+
+	return b
+}
+
+// NFLog logs matching packets via NFLOG to g.FilterLogGroup with the name of the rule as prefix.
+// See g.Host.FilterLog.
+func (b *RuleBuilder) NFLog(name string) *RuleBuilder {
+	b.statements = append(b.statements, NFLog(g.FilterLogGroup, name))
+	return b
+}
+
+// Build returns the rule without a verdict.
+func (b *RuleBuilder) Build() g.FilterRule {
+	return b.build()
+}
+
+// Accept builds the rule with a verdict accepting matching packets.
+func (b *RuleBuilder) Accept() g.FilterRule {
+	return b.build(&expr.Verdict{
+		Kind: expr.VerdictAccept,
+	})
+}
+
+// Drop builds the rule with a verdict silently dropping matching packets.
+func (b *RuleBuilder) Drop() g.FilterRule {
+	return b.build(&expr.Verdict{
+		Kind: expr.VerdictDrop,
+	})
+}
+
+// LogDrop builds the rule with a verdict dropping matching packets
+// after logging them via NFLOG. See NFLog().
+func (b *RuleBuilder) LogDrop(name string) g.FilterRule {
+	return b.NFLog(name).Drop()
+}
+
+// Reject builds the rule with a verdict rejecting matching packets with an ICMP error.
+func (b *RuleBuilder) Reject(code RejectCode) g.FilterRule {
+	return b.build(&expr.Reject{
+		Type: unix.NFT_REJECT_ICMPX_UNREACH,
+		Code: uint8(code),
+	})
+}
+
+// RejectTCPReset builds the rule with a verdict rejecting matching TCP segments with a reset.
+func (b *RuleBuilder) RejectTCPReset() g.FilterRule {
+	b.Proto(TCP)
+
+	return b.build(&expr.Reject{
+		Type: unix.NFT_REJECT_TCP_RST,
+	})
+}
+
+func (b *RuleBuilder) build(verdict ...expr.Any) g.FilterRule {
+	exprs := []expr.Any{}
+
+	if b.family != 0 {
+		exprs = append(exprs, Protocol(b.family)...)
+	}
+
+	if b.proto >= 0 {
+		exprs = append(exprs, TransportProtocol(b.proto)...)
+	}
+
+	for _, stmts := range [][]Statement{b.matches, b.statements} {
+		for _, stmt := range stmts {
+			exprs = append(exprs, stmt...)
+		}
+	}
+
+	exprs = append(exprs, verdict...)
+
+	return g.FilterRule{
+		Hook:  b.hook,
+		Exprs: exprs,
+	}
+}
+
+func (b *RuleBuilder) setFamily(family int) {
+	if b.family != 0 && b.family != family {
+		panic(errors.New("rule mixes IPv4 and IPv6"))
+	}
+
+	b.family = family
+}
+
+func (b *RuleBuilder) requirePorts() {
+	switch b.proto {
+	case TCP, UDP, SCTP:
+	case -1:
+		panic(errors.New("ports require a transport protocol"))
+	default:
+		panic(fmt.Errorf("protocol %d has no ports", b.proto))
+	}
+}
+
+// network matches the address of packets in the given direction.
+// Multiple addresses of a node are matched via an anonymous set.
+func (b *RuleBuilder) network(dir direction, addr any) Statement {
+	netws := b.prefixes(addr)
+	if len(netws) == 1 {
+		return network(dir, netws[0])
+	}
+
+	typ := g.FilterSetIPv6Addr
+	if b.family == unix.AF_INET {
+		typ = g.FilterSetIPv4Addr
+	}
+
+	elems := []any{}
+	for _, netw := range netws {
+		elems = append(elems, netw)
+	}
+
+	offset, length := ipOffsetLen(netws[0].IP, dir)
+
+	return Statement{
+		&expr.Payload{
+			DestRegister: 1,
+			Base:         expr.PayloadBaseNetworkHeader,
+			Offset:       offset,
+			Len:          length,
+		},
+		&g.FilterAnonymousSet{
+			Lookup: &expr.Lookup{
+				SourceRegister: 1,
+			},
+			Set: g.FilterSet{
+				Type:     typ,
+				Elements: elems,
+			},
+		},
+	}
+}
+
+// prefixes converts an address into prefixes and restricts the rule to their address family.
+// Only the addresses of a node which belong to the family of its first address are used.
+func (b *RuleBuilder) prefixes(addr any) []*net.IPNet {
+	network := "ip"
+	switch b.family {
+	case unix.AF_INET:
+		network = "ip4"
+	case unix.AF_INET6:
+		network = "ip6"
+	}
+
+	all, err := utils.Prefixes(addr, network)
+	if err != nil {
+		panic(err)
+	}
+
+	isV4 := all[0].IP.To4() != nil
+
+	netws := []*net.IPNet{}
+	for _, netw := range all {
+		if (netw.IP.To4() != nil) == isV4 {
+			netws = append(netws, netw)
+		}
+	}
+
+	if isV4 {
+		b.setFamily(unix.AF_INET)
+	} else {
+		b.setFamily(unix.AF_INET6)
+	}
+
+	return netws
+}
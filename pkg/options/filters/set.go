@@ -0,0 +1,93 @@
+// SPDX-FileCopyrightText: 2023 Steffen Vogel <post@steffenvogel.de>
+// SPDX-License-Identifier: Apache-2.0
+
+package filters
+
+import (
+	"net"
+
+	"github.com/google/nftables/expr"
+	"golang.org/x/sys/unix"
+)
+
+// SetKey is the packet field which is looked up in a named set.
+type SetKey int
+
+const (
+	SetKeySourceIPv4      SetKey = iota // Matches ipv4_addr sets
+	SetKeyDestinationIPv4               // Matches ipv4_addr sets
+	SetKeySourceIPv6                    // Matches ipv6_addr sets
+	SetKeyDestinationIPv6               // Matches ipv6_addr sets
+	SetKeySourcePort                    // Matches inet_service sets
+	SetKeyDestinationPort               // Matches inet_service sets
+)
+
+// family returns the address family of address keys or 0 for port keys.
+func (k SetKey) family() int {
+	switch k {
+	case SetKeySourceIPv4, SetKeyDestinationIPv4:
+		return unix.AF_INET
+	case SetKeySourceIPv6, SetKeyDestinationIPv6:
+		return unix.AF_INET6
+	default:
+		return 0
+	}
+}
+
+func (k SetKey) payload() *expr.Payload {
+	p := &expr.Payload{
+		DestRegister: 1,
+		Base:         expr.PayloadBaseNetworkHeader,
+	}
+
+	switch k {
+	case SetKeySourceIPv4:
+		p.Offset, p.Len = 12, net.IPv4len
+	case SetKeyDestinationIPv4:
+		p.Offset, p.Len = 16, net.IPv4len
+	case SetKeySourceIPv6:
+		p.Offset, p.Len = 8, net.IPv6len
+	case SetKeyDestinationIPv6:
+		p.Offset, p.Len = 24, net.IPv6len
+	case SetKeySourcePort:
+		p.Base, p.Offset, p.Len = expr.PayloadBaseTransportHeader, 0, 2
+	case SetKeyDestinationPort:
+		p.Base, p.Offset, p.Len = expr.PayloadBaseTransportHeader, 2, 2
+	}
+
+	return p
+}
+
+func lookup(name string, key SetKey, invert bool) Statement {
+	return Statement{
+		key.payload(),
+		&expr.Lookup{
+			SourceRegister: 1,
+			SetName:        name,
+			Invert:         invert,
+		},
+	}
+}
+
+func inSet(name string, key SetKey, invert bool) Statement {
+	stmt := Statement{}
+
+	if f := key.family(); f != 0 {
+		stmt = append(stmt, Protocol(f)...)
+	}
+
+	return append(stmt, lookup(name, key, invert)...)
+}
+
+// InSet matches packets whose field is contained in the named set of the host's filter.
+// See g.Filter.AddSet().
+//
+// Port keys require a preceding match of the transport protocol.
+func InSet(name string, key SetKey) Statement {
+	return inSet(name, key, false)
+}
+
+// NotInSet matches packets whose field is not contained in the named set of the host's filter.
+func NotInSet(name string, key SetKey) Statement {
+	return inSet(name, key, true)
+}
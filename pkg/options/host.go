@@ -30,6 +30,13 @@ func RouteNet(network net.IPNet, gw net.IP) Route {
 	}
 }
 
+// Table selects the routing table to which a route is added.
+type Table uint32
+
+func (t Table) ApplyRoute(r *nl.Route) {
+	r.Table = int(t)
+}
+
 func DefaultGatewayIPv4(a, b, c, d byte) Route {
 	return RouteNet(g.DefaultIPv4Mask, net.IPv4(a, b, c, d))
 }
@@ -59,3 +66,11 @@ func Filter(h g.FilterHook, stmts ...filters.Statement) g.FilterRule {
 
 	return r
 }
+
+// FilterLog reports packets which are logged by filter rules to
+// g.FilterLogGroup as events to the tracer and as annotated packets to the captures of the host.
+type FilterLog bool
+
+func (fl FilterLog) ApplyHost(h *g.Host) {
+	h.FilterLog = bool(fl)
+}
@@ -0,0 +1,35 @@
+// SPDX-FileCopyrightText: 2023 Steffen Vogel <post@steffenvogel.de>
+// SPDX-License-Identifier: Apache-2.0
+
+package options
+
+import (
+	nl "github.com/vishvananda/netlink"
+)
+
+// MACVLANMode sets the mode of a MACVLAN interface.
+type MACVLANMode nl.MacvlanMode
+
+const (
+	MACVLANModePrivate  = MACVLANMode(nl.MACVLAN_MODE_PRIVATE)
+	MACVLANModeVEPA     = MACVLANMode(nl.MACVLAN_MODE_VEPA)
+	MACVLANModeBridge   = MACVLANMode(nl.MACVLAN_MODE_BRIDGE)
+	MACVLANModePassthru = MACVLANMode(nl.MACVLAN_MODE_PASSTHRU)
+)
+
+func (m MACVLANMode) ApplyMacvlan(mv *nl.Macvlan) {
+	mv.Mode = nl.MacvlanMode(m)
+}
+
+// IPVLANMode sets the mode of an IPVLAN interface.
+type IPVLANMode nl.IPVlanMode
+
+const (
+	IPVLANModeL2  = IPVLANMode(nl.IPVLAN_MODE_L2)
+	IPVLANModeL3  = IPVLANMode(nl.IPVLAN_MODE_L3)
+	IPVLANModeL3S = IPVLANMode(nl.IPVLAN_MODE_L3S)
+)
+
+func (m IPVLANMode) ApplyIPVlan(iv *nl.IPVlan) {
+	iv.Mode = nl.IPVlanMode(m)
+}
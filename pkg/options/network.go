@@ -27,3 +27,13 @@ type IPv6Disabled bool
 func (d IPv6Disabled) ApplyNetwork(n *g.Network) {
 	n.IPv6Disabled = bool(d)
 }
+
+// RoutingTable assigns a name to a routing table.
+type RoutingTable struct {
+	ID   uint32
+	Name string
+}
+
+func (t RoutingTable) ApplyNetwork(n *g.Network) {
+	n.RoutingTables[t.ID] = t.Name
+}
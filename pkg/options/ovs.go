@@ -0,0 +1,48 @@
+// SPDX-FileCopyrightText: 2023 Steffen Vogel <post@steffenvogel.de>
+// SPDX-License-Identifier: Apache-2.0
+
+package options
+
+import (
+	g "cunicu.li/gont/v2/pkg"
+)
+
+// Controller adds an OpenFlow controller target to an Open vSwitch bridge,
+// e.g. "tcp:10.0.0.1:6653".
+type Controller string
+
+func (c Controller) ApplyOVSSwitch(sw *g.OVSSwitch) {
+	sw.Controllers = append(sw.Controllers, string(c))
+}
+
+// OpenFlowVersion adds a supported OpenFlow version to an Open vSwitch bridge,
+// e.g. "OpenFlow13".
+type OpenFlowVersion string
+
+func (v OpenFlowVersion) ApplyOVSSwitch(sw *g.OVSSwitch) {
+	sw.Protocols = append(sw.Protocols, string(v))
+}
+
+// FailMode configures the behaviour of an Open vSwitch bridge
+// while it is not connected to a controller.
+type FailMode string
+
+const (
+	// FailModeStandalone lets the bridge act as a learning switch.
+	FailModeStandalone FailMode = "standalone"
+
+	// FailModeSecure only forwards according to the installed flows.
+	FailModeSecure FailMode = "secure"
+)
+
+func (m FailMode) ApplyOVSSwitch(sw *g.OVSSwitch) {
+	sw.FailMode = string(m)
+}
+
+// DatapathType selects the datapath of an Open vSwitch bridge.
+// Use "netdev" for the user-space datapath if the openvswitch kernel module is not available.
+type DatapathType string
+
+func (t DatapathType) ApplyOVSSwitch(sw *g.OVSSwitch) {
+	sw.DatapathType = string(t)
+}
@@ -0,0 +1,123 @@
+// SPDX-FileCopyrightText: 2023 Steffen Vogel <post@steffenvogel.de>
+// SPDX-License-Identifier: Apache-2.0
+
+// Package replay contains the options to configure the replay of recorded packets
+package replay
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"strings"
+
+	"cunicu.li/gont/v2/internal/utils"
+	g "cunicu.li/gont/v2/pkg"
+	"github.com/gopacket/gopacket/layers"
+)
+
+var (
+	errInvalidAddress = errors.New("invalid address")
+	errFamilyMismatch = errors.New("address families do not match")
+)
+
+// Timing sends packets according to the time differences of their original timestamps.
+type Timing bool
+
+func (t Timing) ApplyReplay(o *g.ReplayOptions) {
+	o.Timing = bool(t)
+}
+
+// Speed is a multiplier for the original timing.
+// E.g. a speed of 2 replays the packets twice as fast as recorded.
+// It implies Timing(true).
+type Speed float64
+
+func (s Speed) ApplyReplay(o *g.ReplayOptions) {
+	o.Timing = true
+	o.Speed = float64(s)
+}
+
+// LinkType overwrites the link-type of the recorded packets.
+type LinkType layers.LinkType
+
+func (lt LinkType) ApplyReplay(o *g.ReplayOptions) {
+	o.LinkType = layers.LinkType(lt)
+}
+
+// SourceMAC replaces the source MAC address of all packets
+// with the address of the interface they are sent from.
+type SourceMAC bool
+
+func (sm SourceMAC) ApplyReplay(o *g.ReplayOptions) {
+	o.SourceMAC = bool(sm)
+}
+
+type macMapping struct {
+	from, to net.HardwareAddr
+}
+
+func (m macMapping) ApplyReplay(o *g.ReplayOptions) {
+	o.MACs[m.from.String()] = m.to
+}
+
+// MapMAC rewrites a recorded MAC address in source and destination fields.
+func MapMAC(from, to net.HardwareAddr) g.ReplayOption {
+	return macMapping{from, to}
+}
+
+type ipMapping struct {
+	from, to netip.Addr
+}
+
+func (m ipMapping) ApplyReplay(o *g.ReplayOptions) {
+	o.IPs[m.from] = m.to
+}
+
+// MapIP rewrites a recorded IP address in source and destination fields.
+//
+// The new address can be a net.IP, a string or a node like *g.Host or *g.Router
+// whose first address of the same address family is used.
+// Checksums are updated accordingly.
+// Invalid addresses, prefixes and addresses of different families panic.
+func MapIP(from net.IP, to any) g.ReplayOption {
+	fromAddr, ok := netip.AddrFromSlice(from)
+	if !ok {
+		panic(fmt.Errorf("%w: %s", errInvalidAddress, from))
+	}
+
+	fromAddr = fromAddr.Unmap()
+
+	// Only single addresses can be mapped
+	switch to := to.(type) {
+	case *net.IPNet:
+		panic(fmt.Errorf("%w: prefix %s", errInvalidAddress, to))
+	case string:
+		if strings.Contains(to, "/") {
+			panic(fmt.Errorf("%w: prefix %s", errInvalidAddress, to))
+		}
+	}
+
+	network := "ip6"
+	if fromAddr.Is4() {
+		network = "ip4"
+	}
+
+	netws, err := utils.Prefixes(to, network)
+	if err != nil {
+		panic(err)
+	}
+
+	toAddr, ok := netip.AddrFromSlice(netws[0].IP)
+	if !ok {
+		panic(fmt.Errorf("%w: %v", errInvalidAddress, to))
+	}
+
+	toAddr = toAddr.Unmap()
+
+	if toAddr.Is4() != fromAddr.Is4() {
+		panic(fmt.Errorf("%w: %s and %s", errFamilyMismatch, fromAddr, toAddr))
+	}
+
+	return ipMapping{fromAddr, toAddr}
+}
@@ -0,0 +1,89 @@
+// SPDX-FileCopyrightText: 2023 Steffen Vogel <post@steffenvogel.de>
+// SPDX-License-Identifier: Apache-2.0
+
+package options
+
+import (
+	"net"
+	"time"
+
+	g "cunicu.li/gont/v2/pkg"
+)
+
+// RoutingDaemon runs a dynamic routing daemon on a router.
+//
+// The option only configures the daemon. It is started by calling
+// Router.StartRoutingDaemon() after all links of the router have been added.
+// The daemon's configuration directory is shadowed by a per-node empty directory.
+type RoutingDaemon struct {
+	Suite   g.RoutingSuite
+	Options []g.RoutingDaemonOption
+}
+
+func (d RoutingDaemon) ApplyBaseNode(n *g.BaseNode) {
+	n.EmptyDirs = append(n.EmptyDirs, d.Suite.ConfigDir())
+}
+
+func (d RoutingDaemon) ApplyRouter(r *g.Router) {
+	r.RoutingDaemon = &g.RoutingDaemon{
+		Suite: d.Suite,
+	}
+
+	for _, opt := range d.Options {
+		opt.ApplyRoutingDaemon(r.RoutingDaemon)
+	}
+}
+
+// FRR runs the FRRouting suite on a router.
+func FRR(opts ...g.RoutingDaemonOption) RoutingDaemon {
+	return RoutingDaemon{
+		Suite:   g.RoutingSuiteFRR,
+		Options: opts,
+	}
+}
+
+// BIRD runs the BIRD Internet Routing Daemon on a router.
+func BIRD(opts ...g.RoutingDaemonOption) RoutingDaemon {
+	return RoutingDaemon{
+		Suite:   g.RoutingSuiteBIRD,
+		Options: opts,
+	}
+}
+
+// BGP enables BGP using the given autonomous system number.
+type BGP uint32
+
+func (asn BGP) ApplyRoutingDaemon(d *g.RoutingDaemon) {
+	d.ASN = uint32(asn)
+}
+
+// OSPF enables OSPFv2 for all IPv4 networks of the router.
+const OSPF = OSPFEnabled(true)
+
+type OSPFEnabled bool
+
+func (e OSPFEnabled) ApplyRoutingDaemon(d *g.RoutingDaemon) {
+	d.OSPF = bool(e)
+}
+
+// RouterID overwrites the router ID which defaults to the first IPv4 address of the router.
+type RouterID net.IP
+
+func (id RouterID) ApplyRoutingDaemon(d *g.RoutingDaemon) {
+	d.RouterID = net.IP(id)
+}
+
+// RoutingAgentPort sets the UDP port on which routing agents exchange their routing tables.
+type RoutingAgentPort int
+
+func (p RoutingAgentPort) ApplyRoutingAgent(a *g.RoutingAgent) {
+	a.Port = int(p)
+}
+
+// RoutingAgentInterval sets the interval in which routing agents advertise their routing tables.
+// Routes are withdrawn after three intervals without an advertisement.
+type RoutingAgentInterval time.Duration
+
+func (i RoutingAgentInterval) ApplyRoutingAgent(a *g.RoutingAgent) {
+	a.Interval = time.Duration(i)
+}
@@ -0,0 +1,94 @@
+// SPDX-FileCopyrightText: 2023 Steffen Vogel <post@steffenvogel.de>
+// SPDX-License-Identifier: Apache-2.0
+
+package options
+
+import (
+	"fmt"
+	"net"
+
+	nl "github.com/vishvananda/netlink"
+)
+
+func (t Table) ApplyRule(r *nl.Rule) {
+	r.Table = int(t)
+}
+
+// RulePriority sets the preference of a policy routing rule.
+// Rules with lower values are evaluated first.
+type RulePriority int
+
+func (p RulePriority) ApplyRule(r *nl.Rule) {
+	r.Priority = int(p)
+}
+
+// RuleFrom matches packets by their source prefix.
+type RuleFrom net.IPNet
+
+func (f RuleFrom) ApplyRule(r *nl.Rule) {
+	n := net.IPNet(f)
+	r.Src = &n
+}
+
+// RuleTo matches packets by their destination prefix.
+type RuleTo net.IPNet
+
+func (t RuleTo) ApplyRule(r *nl.Rule) {
+	n := net.IPNet(t)
+	r.Dst = &n
+}
+
+func RuleFromIP(fmts string, args ...any) RuleFrom {
+	return RuleFrom(parsePrefix(fmts, args...))
+}
+
+func RuleToIP(fmts string, args ...any) RuleTo {
+	return RuleTo(parsePrefix(fmts, args...))
+}
+
+// RuleFwMark matches packets by their firewall mark.
+type RuleFwMark uint32
+
+func (m RuleFwMark) ApplyRule(r *nl.Rule) {
+	r.Mark = uint32(m)
+}
+
+// RuleFwMarkMask restricts the comparison of the firewall mark to the bits set in the mask.
+type RuleFwMarkMask uint32
+
+func (m RuleFwMarkMask) ApplyRule(r *nl.Rule) {
+	mask := uint32(m)
+	r.Mask = &mask
+}
+
+// RuleInputInterface matches packets by the name of the interface they have been received on.
+type RuleInputInterface string
+
+func (i RuleInputInterface) ApplyRule(r *nl.Rule) {
+	r.IifName = string(i)
+}
+
+// RuleOutputInterface matches packets by the name of the interface they are sent out from.
+type RuleOutputInterface string
+
+func (i RuleOutputInterface) ApplyRule(r *nl.Rule) {
+	r.OifName = string(i)
+}
+
+// RuleInvert inverts the selector of a rule.
+type RuleInvert bool
+
+func (i RuleInvert) ApplyRule(r *nl.Rule) {
+	r.Invert = bool(i)
+}
+
+func parsePrefix(fmts string, args ...any) net.IPNet {
+	str := fmt.Sprintf(fmts, args...)
+
+	_, n, err := net.ParseCIDR(str)
+	if err != nil {
+		panic(fmt.Errorf("failed to parse prefix '%s': %w", str, err))
+	}
+
+	return *n
+}
@@ -66,3 +66,15 @@ func (c Capture) ApplyTracer(t *g.Tracer) {
 }
 
 func ToCapture(c *g.Capture) Capture { return Capture{c} }
+
+// Expose serves the tracing events of a network as a PCAPng stream via a UNIX socket
+// in the runtime directory of the network.
+//
+// This allows to attach to the events of a running network with gontc or WireShark:
+//
+//	gontc extcap --extcap-interfaces
+type Expose bool
+
+func (e Expose) ApplyTracer(t *g.Tracer) {
+	t.Expose = bool(e)
+}
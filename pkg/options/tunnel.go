@@ -0,0 +1,41 @@
+// SPDX-FileCopyrightText: 2023 Steffen Vogel <post@steffenvogel.de>
+// SPDX-License-Identifier: Apache-2.0
+
+package options
+
+import (
+	nl "github.com/vishvananda/netlink"
+)
+
+// TTL sets the time-to-live of the outer IP header of encapsulated packets.
+type TTL uint8
+
+func (t TTL) ApplyVxlan(vx *nl.Vxlan) {
+	vx.TTL = int(t)
+}
+
+func (t TTL) ApplyGretun(gt *nl.Gretun) {
+	gt.Ttl = uint8(t)
+}
+
+// VXLANPort sets the UDP destination port of a VXLAN tunnel.
+type VXLANPort int
+
+func (p VXLANPort) ApplyVxlan(vx *nl.Vxlan) {
+	vx.Port = int(p)
+}
+
+// VXLANLearning enables learning of remote MAC addresses in the FDB of a VXLAN tunnel.
+type VXLANLearning bool
+
+func (l VXLANLearning) ApplyVxlan(vx *nl.Vxlan) {
+	vx.Learning = bool(l)
+}
+
+// GREKey sets the key which is included in the GRE header of a tunnel.
+type GREKey uint32
+
+func (k GREKey) ApplyGretun(gt *nl.Gretun) {
+	gt.IKey = uint32(k)
+	gt.OKey = uint32(k)
+}
@@ -0,0 +1,28 @@
+// SPDX-FileCopyrightText: 2023 Steffen Vogel <post@steffenvogel.de>
+// SPDX-License-Identifier: Apache-2.0
+
+package options
+
+import (
+	"time"
+
+	"golang.zx2c4.com/wireguard/wgctrl/wgtypes"
+)
+
+// WireGuardListenPort sets the UDP port on which both ends of a WireGuard tunnel are listening.
+type WireGuardListenPort int
+
+func (p WireGuardListenPort) ApplyWireGuard(cfg *wgtypes.Config) {
+	port := int(p)
+	cfg.ListenPort = &port
+}
+
+// WireGuardPersistentKeepalive sets the interval in which keepalive packets are sent to the peer.
+type WireGuardPersistentKeepalive time.Duration
+
+func (k WireGuardPersistentKeepalive) ApplyWireGuard(cfg *wgtypes.Config) {
+	ka := time.Duration(k)
+	for i := range cfg.Peers {
+		cfg.Peers[i].PersistentKeepaliveInterval = &ka
+	}
+}
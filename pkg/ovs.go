@@ -0,0 +1,337 @@
+// SPDX-FileCopyrightText: 2023 Steffen Vogel <post@steffenvogel.de>
+// SPDX-License-Identifier: Apache-2.0
+
+package gont
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"go.uber.org/zap"
+)
+
+type OVSSwitchOption interface {
+	ApplyOVSSwitch(sw *OVSSwitch)
+}
+
+// OVSSwitch is an abstraction for an Open vSwitch bridge
+//
+// Each OVSSwitch runs its own ovsdb-server and ovs-vswitchd
+// daemons within the network namespace of the node.
+type OVSSwitch struct {
+	*BaseNode
+
+	// Options
+	Controllers  []string // OpenFlow controller targets, e.g. "tcp:10.0.0.1:6653"
+	Protocols    []string // Supported OpenFlow versions, e.g. "OpenFlow13"
+	FailMode     string   // Either "standalone" or "secure"
+	DatapathType string   // Either "system" (kernel) or "netdev" (user-space)
+
+	ovsdbServer *Cmd
+	ovsVSwitchd *Cmd
+}
+
+// Options
+
+func (sw *OVSSwitch) ApplyInterface(i *Interface) {
+	i.Node = sw
+}
+
+// AddOVSSwitch adds a new Open vSwitch bridge in a dedicated namespace
+func (n *Network) AddOVSSwitch(name string, opts ...Option) (*OVSSwitch, error) {
+	node, err := n.AddNode(name, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create node: %w", err)
+	}
+
+	sw := &OVSSwitch{
+		BaseNode: node,
+	}
+
+	n.Register(sw)
+
+	// Apply options
+	for _, opt := range opts {
+		if opt, ok := opt.(OVSSwitchOption); ok {
+			opt.ApplyOVSSwitch(sw)
+		}
+	}
+
+	if err := sw.startDaemons(); err != nil {
+		return nil, err
+	}
+
+	n.logger.Info("Adding new Open vSwitch bridge",
+		zap.Any("node", sw),
+		zap.String("intf", bridgeInterfaceName),
+	)
+
+	if err := sw.addBridge(); err != nil {
+		return nil, err
+	}
+
+	// Connect host to switch interfaces
+	for _, intf := range sw.Interfaces {
+		peerDev := fmt.Sprintf("veth-%s", name)
+
+		left := intf
+		left.Node = sw
+
+		right := &Interface{
+			Name: peerDev,
+			Node: intf.Node,
+		}
+
+		if err := n.AddLink(left, right); err != nil {
+			return nil, fmt.Errorf("failed to add link: %w", err)
+		}
+	}
+
+	return sw, nil
+}
+
+// ConfigureInterface adds an existing interface as a port to the bridge
+func (sw *OVSSwitch) ConfigureInterface(i *Interface) error {
+	sw.logger.Info("Adding interface as bridge port", zap.Any("intf", i))
+
+	if _, err := sw.VSCtl("add-port", bridgeInterfaceName, i.Name); err != nil {
+		return fmt.Errorf("failed to add bridge port: %w", err)
+	}
+
+	return sw.BaseNode.ConfigureInterface(i)
+}
+
+func (sw *OVSSwitch) Close() error {
+	return errors.Join(
+		sw.stopDaemons(),
+		sw.BaseNode.Close(),
+	)
+}
+
+func (sw *OVSSwitch) Teardown() error {
+	return errors.Join(
+		sw.stopDaemons(),
+		sw.BaseNode.Teardown(),
+	)
+}
+
+// AddFlows installs OpenFlow flows in the bridge.
+// The flows use the syntax of ovs-ofctl(8), e.g. "priority=100,in_port=1,actions=output:2".
+func (sw *OVSSwitch) AddFlows(flows ...string) error {
+	for _, flow := range flows {
+		if _, err := sw.OFCtl("add-flow", flow); err != nil {
+			return fmt.Errorf("failed to add flow: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// DeleteFlows removes all flows from the bridge which match the given flow description.
+// An empty match removes all flows.
+func (sw *OVSSwitch) DeleteFlows(match string) error {
+	args := []any{}
+	if match != "" {
+		args = append(args, match)
+	}
+
+	if _, err := sw.OFCtl("del-flows", args...); err != nil {
+		return fmt.Errorf("failed to delete flows: %w", err)
+	}
+
+	return nil
+}
+
+// Flows returns the flows currently installed in the bridge as printed by ovs-ofctl(8).
+func (sw *OVSSwitch) Flows() ([]string, error) {
+	out, err := sw.OFCtl("dump-flows", "--no-stats")
+	if err != nil {
+		return nil, fmt.Errorf("failed to dump flows: %w", err)
+	}
+
+	flows := []string{}
+
+	s := bufio.NewScanner(bytes.NewReader(out))
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+
+		// Skip reply headers like "NXST_FLOW reply (xid=0x4):"
+		if line == "" || strings.Contains(line, "reply") {
+			continue
+		}
+
+		flows = append(flows, line)
+	}
+
+	return flows, s.Err()
+}
+
+// VSCtl runs ovs-vsctl(8) against the database of the switch.
+func (sw *OVSSwitch) VSCtl(args ...any) ([]byte, error) {
+	args = append([]any{"--timeout=10"}, args...)
+
+	return sw.ovsRun("ovs-vsctl", args...)
+}
+
+// OFCtl runs an ovs-ofctl(8) command against the bridge of the switch.
+func (sw *OVSSwitch) OFCtl(cmd string, args ...any) ([]byte, error) {
+	args = append([]any{cmd, bridgeInterfaceName}, args...)
+
+	if len(sw.Protocols) > 0 {
+		args = append([]any{"--protocols", strings.Join(sw.Protocols, ",")}, args...)
+	}
+
+	return sw.ovsRun("ovs-ofctl", args...)
+}
+
+func (sw *OVSSwitch) startDaemons() error {
+	dir := sw.ovsDir()
+
+	if _, err := sw.ovsRun("ovsdb-tool", "create", filepath.Join(dir, "conf.db")); err != nil {
+		return fmt.Errorf("failed to create database: %w", err)
+	}
+
+	var err error
+	if sw.ovsdbServer, err = sw.ovsStart("ovsdb-server", filepath.Join(dir, "conf.db"),
+		"--remote=punix:"+filepath.Join(dir, "db.sock"),
+		"--pidfile"); err != nil {
+		return fmt.Errorf("failed to start ovsdb-server: %w", err)
+	}
+
+	if err := waitForFile(filepath.Join(dir, "db.sock")); err != nil {
+		return fmt.Errorf("failed to wait for ovsdb-server: %w", err)
+	}
+
+	if _, err := sw.VSCtl("--no-wait", "init"); err != nil {
+		return fmt.Errorf("failed to initialize database: %w", err)
+	}
+
+	if sw.ovsVSwitchd, err = sw.ovsStart("ovs-vswitchd",
+		"unix:"+filepath.Join(dir, "db.sock"),
+		"--pidfile"); err != nil {
+		return fmt.Errorf("failed to start ovs-vswitchd: %w", err)
+	}
+
+	return nil
+}
+
+// stopDaemons stops all running daemons even if stopping one of them fails.
+func (sw *OVSSwitch) stopDaemons() error {
+	var errs []error
+
+	for _, c := range []**Cmd{&sw.ovsVSwitchd, &sw.ovsdbServer} {
+		if *c == nil {
+			continue
+		}
+
+		// The daemon might have already exited on its own
+		if err := (*c).Process.Signal(syscall.SIGTERM); err == nil || errors.Is(err, os.ErrProcessDone) {
+			(*c).Wait() //nolint:errcheck
+		} else {
+			errs = append(errs, fmt.Errorf("failed to stop %s: %w", (*c).Path, err))
+		}
+
+		*c = nil
+	}
+
+	return errors.Join(errs...)
+}
+
+func (sw *OVSSwitch) addBridge() error {
+	args := []any{"add-br", bridgeInterfaceName}
+
+	if sw.DatapathType != "" {
+		args = append(args, "--", "set", "bridge", bridgeInterfaceName, "datapath_type="+sw.DatapathType)
+	}
+
+	if sw.FailMode != "" {
+		args = append(args, "--", "set-fail-mode", bridgeInterfaceName, sw.FailMode)
+	}
+
+	if len(sw.Protocols) > 0 {
+		args = append(args, "--", "set", "bridge", bridgeInterfaceName, "protocols="+strings.Join(sw.Protocols, ","))
+	}
+
+	if len(sw.Controllers) > 0 {
+		args = append(args, "--", "set-controller", bridgeInterfaceName)
+		for _, c := range sw.Controllers {
+			args = append(args, c)
+		}
+	}
+
+	if _, err := sw.VSCtl(args...); err != nil {
+		return fmt.Errorf("failed to add bridge: %w", err)
+	}
+
+	br, err := sw.nlHandle.LinkByName(bridgeInterfaceName)
+	if err != nil {
+		return fmt.Errorf("failed to find bridge interface: %w", err)
+	}
+
+	if err := sw.nlHandle.LinkSetUp(br); err != nil {
+		return fmt.Errorf("failed to bring bridge up: %w", err)
+	}
+
+	return nil
+}
+
+// ovsDir returns the directory holding the database, sockets and logs of the switch.
+func (sw *OVSSwitch) ovsDir() string {
+	return filepath.Join(sw.VarPath, "ovs")
+}
+
+// ovsCommand prepares a command which uses the private directories of the switch
+// instead of the system-wide Open vSwitch instance.
+func (sw *OVSSwitch) ovsCommand(name string, args ...any) *Cmd {
+	c := sw.Command(name, args...)
+
+	dir := sw.ovsDir()
+	for _, env := range []string{"OVS_RUNDIR", "OVS_DBDIR", "OVS_LOGDIR", "OVS_SYSCONFDIR"} {
+		c.Env = append(c.Env, env+"="+dir)
+	}
+
+	return c
+}
+
+func (sw *OVSSwitch) ovsStart(name string, args ...any) (*Cmd, error) {
+	if err := sw.ensureOVSDir(); err != nil {
+		return nil, err
+	}
+
+	c := sw.ovsCommand(name, args...)
+
+	return c, c.Start()
+}
+
+func (sw *OVSSwitch) ovsRun(name string, args ...any) ([]byte, error) {
+	if err := sw.ensureOVSDir(); err != nil {
+		return nil, err
+	}
+
+	out := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+
+	c := sw.ovsCommand(name, args...)
+	c.StdoutWriters = append(c.StdoutWriters, out)
+	c.StderrWriters = append(c.StderrWriters, stderr)
+
+	if err := c.Run(); err != nil {
+		return nil, fmt.Errorf("%s: %w: %s", name, err, strings.TrimSpace(stderr.String()))
+	}
+
+	return out.Bytes(), nil
+}
+
+func (sw *OVSSwitch) ensureOVSDir() error {
+	if err := os.MkdirAll(sw.ovsDir(), 0o755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	return nil
+}
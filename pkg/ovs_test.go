@@ -0,0 +1,60 @@
+// SPDX-FileCopyrightText: 2023 Steffen Vogel <post@steffenvogel.de>
+// SPDX-License-Identifier: Apache-2.0
+
+package gont_test
+
+import (
+	"testing"
+	"time"
+
+	g "cunicu.li/gont/v2/pkg"
+	o "cunicu.li/gont/v2/pkg/options"
+	"github.com/stretchr/testify/require"
+)
+
+// TestOVSSwitchFlows checks that an Open vSwitch bridge
+// in secure fail mode only forwards according to installed flows
+//
+//	h1 <-> sw <-> h2
+func TestOVSSwitchFlows(t *testing.T) {
+	skipIfMissing(t, "ovsdb-server", "ovs-vswitchd", "ovs-vsctl", "ovs-ofctl")
+
+	n, err := g.NewNetwork(*nname)
+	require.NoError(t, err, "Failed to create network")
+	defer n.MustClose()
+
+	sw, err := n.AddOVSSwitch("sw",
+		o.FailModeSecure,
+		o.OpenFlowVersion("OpenFlow13"))
+	require.NoError(t, err, "Failed to add switch")
+
+	h1, err := n.AddHost("h1",
+		g.NewInterface("veth0", sw,
+			o.AddressIP("10.0.0.1/24")))
+	require.NoError(t, err, "Failed to add host")
+
+	h2, err := n.AddHost("h2",
+		g.NewInterface("veth0", sw,
+			o.AddressIP("10.0.0.2/24")))
+	require.NoError(t, err, "Failed to add host")
+
+	_, err = h1.PingWithOptions(h2, "ip", 1, time.Second, time.Second, false)
+	require.Error(t, err, "Ping succeeded without flows")
+
+	err = sw.AddFlows("priority=0,actions=normal")
+	require.NoError(t, err, "Failed to add flow")
+
+	flows, err := sw.Flows()
+	require.NoError(t, err, "Failed to dump flows")
+	require.Len(t, flows, 1)
+
+	err = g.TestConnectivity(h1, h2)
+	require.NoError(t, err, "Failed to check connectivity")
+
+	err = sw.DeleteFlows("")
+	require.NoError(t, err, "Failed to delete flows")
+
+	flows, err = sw.Flows()
+	require.NoError(t, err, "Failed to dump flows")
+	require.Empty(t, flows)
+}
@@ -5,8 +5,14 @@ package gont
 
 import (
 	"net"
+
+	nl "github.com/vishvananda/netlink"
 )
 
+type RouteOption interface {
+	ApplyRoute(r *nl.Route)
+}
+
 //nolint:gochecknoglobals
 var (
 	DefaultIPv4Mask = net.IPNet{
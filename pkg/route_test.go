@@ -0,0 +1,121 @@
+// SPDX-FileCopyrightText: 2023 Steffen Vogel <post@steffenvogel.de>
+// SPDX-License-Identifier: Apache-2.0
+
+package gont_test
+
+import (
+	"fmt"
+	"net"
+	"testing"
+
+	g "cunicu.li/gont/v2/pkg"
+	o "cunicu.li/gont/v2/pkg/options"
+	"github.com/stretchr/testify/require"
+	nl "github.com/vishvananda/netlink"
+)
+
+// TestRouteReplace checks the forwarding decision of a node
+// after changing its routes at runtime
+//
+//	h1 <-> sw <-> h2
+//	       ^----> h3
+func TestRouteReplace(t *testing.T) {
+	n, err := g.NewNetwork(*nname)
+	require.NoError(t, err, "Failed to create network")
+	defer n.MustClose()
+
+	sw, err := n.AddSwitch("sw")
+	require.NoError(t, err, "Failed to create switch")
+
+	h1, err := n.AddHost("h1",
+		g.NewInterface("veth0", sw,
+			o.AddressIP("10.0.0.1/24")))
+	require.NoError(t, err, "Failed to create host")
+
+	for i := 2; i <= 3; i++ {
+		_, err := n.AddHost(fmt.Sprintf("h%d", i),
+			g.NewInterface("veth0", sw,
+				o.AddressIP("10.0.0.%d/24", i),
+				o.AddressIP("10.0.1.1/32")))
+		require.NoError(t, err, "Failed to create host")
+	}
+
+	_, dst, err := net.ParseCIDR("10.0.1.0/24")
+	require.NoError(t, err)
+
+	gw2 := net.IPv4(10, 0, 0, 2)
+	gw3 := net.IPv4(10, 0, 0, 3)
+
+	err = h1.AddRoute(&nl.Route{Dst: dst, Gw: gw2})
+	require.NoError(t, err, "Failed to add route")
+
+	r, err := h1.RouteGet(net.IPv4(10, 0, 1, 1))
+	require.NoError(t, err, "Failed to get route")
+	require.True(t, r.Gw.Equal(gw2))
+
+	_, err = h1.Run("ping", "-c", 1, "-W", 1, "10.0.1.1")
+	require.NoError(t, err, "Failed to ping")
+
+	err = h1.ReplaceRoute(&nl.Route{Dst: dst, Gw: gw3})
+	require.NoError(t, err, "Failed to replace route")
+
+	r, err = h1.RouteGet(net.IPv4(10, 0, 1, 1))
+	require.NoError(t, err, "Failed to get route")
+	require.True(t, r.Gw.Equal(gw3))
+
+	routes, err := h1.RouteList(nl.FAMILY_V4)
+	require.NoError(t, err, "Failed to list routes")
+	require.True(t, containsRoute(routes, dst))
+
+	err = h1.DeleteRoute(&nl.Route{Dst: dst, Gw: gw3})
+	require.NoError(t, err, "Failed to delete route")
+
+	routes, err = h1.RouteList(nl.FAMILY_V4)
+	require.NoError(t, err, "Failed to list routes")
+	require.False(t, containsRoute(routes, dst))
+
+	_, err = h1.RouteGet(net.IPv4(10, 0, 1, 1))
+	require.Error(t, err, "Route has not been withdrawn")
+}
+
+// TestRouteTable checks that routes are listed per routing table
+func TestRouteTable(t *testing.T) {
+	n, err := g.NewNetwork(*nname)
+	require.NoError(t, err, "Failed to create network")
+	defer n.MustClose()
+
+	sw, err := n.AddSwitch("sw")
+	require.NoError(t, err, "Failed to create switch")
+
+	h1, err := n.AddHost("h1",
+		g.NewInterface("veth0", sw,
+			o.AddressIP("10.0.0.1/24")))
+	require.NoError(t, err, "Failed to create host")
+
+	_, dst, err := net.ParseCIDR("10.0.1.0/24")
+	require.NoError(t, err)
+
+	err = h1.AddRoute(&nl.Route{
+		Dst: dst,
+		Gw:  net.IPv4(10, 0, 0, 2),
+	}, o.Table(100))
+	require.NoError(t, err, "Failed to add route")
+
+	routes, err := h1.RouteList(nl.FAMILY_V4)
+	require.NoError(t, err, "Failed to list routes")
+	require.False(t, containsRoute(routes, dst))
+
+	routes, err = h1.RouteList(nl.FAMILY_V4, o.Table(100))
+	require.NoError(t, err, "Failed to list routes")
+	require.True(t, containsRoute(routes, dst))
+}
+
+func containsRoute(routes []nl.Route, dst *net.IPNet) bool {
+	for _, r := range routes {
+		if r.Dst != nil && r.Dst.String() == dst.String() {
+			return true
+		}
+	}
+
+	return false
+}
@@ -3,18 +3,60 @@
 
 package gont
 
+import (
+	"errors"
+	"fmt"
+)
+
 type RouterOption interface {
 	ApplyRouter(r *Router)
 }
 
 type Router struct {
 	*Host
+
+	VRFs []*VRF
+
+	RoutingAgent *RoutingAgent
+
+	// Options
+	RoutingDaemon *RoutingDaemon
 }
 
 func (h *Router) ApplyInterface(i *Interface) {
 	i.Node = h
 }
 
+func (h *Router) Close() error {
+	return errors.Join(
+		h.stopRoutingDaemon(),
+		h.stopRoutingAgent(),
+		h.Host.Close(),
+	)
+}
+
+func (h *Router) Teardown() error {
+	return errors.Join(
+		h.stopRoutingDaemon(),
+		h.stopRoutingAgent(),
+		h.Host.Teardown(),
+	)
+}
+
+func (h *Router) stopRoutingAgent() error {
+	if h.RoutingAgent == nil {
+		return nil
+	}
+
+	if err := h.RoutingAgent.Close(); err != nil {
+		return fmt.Errorf("failed to close routing agent: %w", err)
+	}
+
+	h.RoutingAgent = nil
+
+	return nil
+}
+
 func (n *Network) AddRouter(name string, opts ...Option) (*Router, error) {
 	host, err := n.AddHost(name, opts...)
 	if err != nil {
@@ -29,6 +71,13 @@ func (n *Network) AddRouter(name string, opts ...Option) (*Router, error) {
 		Host: host,
 	}
 
+	// Apply router options
+	for _, o := range opts {
+		if opt, ok := o.(RouterOption); ok {
+			opt.ApplyRouter(rtr)
+		}
+	}
+
 	n.Register(rtr)
 
 	return rtr, nil
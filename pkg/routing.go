@@ -0,0 +1,328 @@
+// SPDX-FileCopyrightText: 2023 Steffen Vogel <post@steffenvogel.de>
+// SPDX-License-Identifier: Apache-2.0
+
+package gont
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+var (
+	errNoRoutingDaemon      = errors.New("router has no routing daemon configured")
+	errRoutingDaemonStarted = errors.New("routing daemon has already been started")
+	errNoRouterID           = errors.New("failed to find IPv4 address for router ID")
+	errUnsupportedSuite     = errors.New("unsupported routing suite")
+	errTimeout              = errors.New("timed out")
+)
+
+// RoutingSuite selects the implementation of the routing daemon.
+type RoutingSuite string
+
+const (
+	RoutingSuiteFRR  RoutingSuite = "frr"
+	RoutingSuiteBIRD RoutingSuite = "bird"
+)
+
+// ConfigDir returns the directory in which the routing suite expects its configuration.
+// It is shadowed by an empty per-node directory for each router.
+func (s RoutingSuite) ConfigDir() string {
+	return filepath.Join("/etc", string(s))
+}
+
+type RoutingDaemonOption interface {
+	ApplyRoutingDaemon(d *RoutingDaemon)
+}
+
+// RoutingDaemon describes a dynamic routing daemon running on a Router.
+//
+// Its configuration is rendered from the topology of the network:
+// all directly connected networks are announced, and
+// routers with a routing daemon in a common subnet become neighbours.
+//
+// The daemon is not started when the router is created, as its links do not exist yet.
+// Router.StartRoutingDaemon() starts it once the topology is complete.
+// The daemon processes are supervised and stopped when the router is closed.
+type RoutingDaemon struct {
+	Suite RoutingSuite
+
+	// Options
+	ASN      uint32 // Enables BGP with the given autonomous system number
+	OSPF     bool   // Enables OSPFv2 in the backbone area
+	RouterID net.IP // Defaults to the first IPv4 address of the router
+
+	router   *Router
+	cmds     []*Cmd
+	exited   sync.WaitGroup
+	stopping atomic.Bool
+}
+
+// routingNeighbor is a router with a routing daemon which shares a subnet with us.
+type routingNeighbor struct {
+	Router    *Router
+	Interface *Interface // Our interface towards the neighbor
+	Address   net.IP     // The neighbor's address in the common subnet
+}
+
+// StartRoutingDaemon renders the configuration of the routing daemon and starts it.
+//
+// As the configuration is derived from the current topology,
+// the daemon should be started after all links of the router have been added.
+func (r *Router) StartRoutingDaemon() error {
+	d := r.RoutingDaemon
+	if d == nil {
+		return errNoRoutingDaemon
+	} else if d.cmds != nil {
+		return errRoutingDaemonStarted
+	}
+
+	d.router = r
+
+	if d.RouterID == nil {
+		if d.RouterID = r.routerID(); d.RouterID == nil {
+			return errNoRouterID
+		}
+	}
+
+	r.logger.Info("Starting routing daemon",
+		zap.String("suite", string(d.Suite)),
+		zap.Uint32("asn", d.ASN),
+		zap.Bool("ospf", d.OSPF),
+		zap.Stringer("router_id", d.RouterID),
+	)
+
+	var cmds []*Cmd
+	var err error
+
+	switch d.Suite {
+	case RoutingSuiteFRR:
+		cmds, err = d.startFRR()
+	case RoutingSuiteBIRD:
+		cmds, err = d.startBIRD()
+	default:
+		return fmt.Errorf("%w: %s", errUnsupportedSuite, d.Suite)
+	}
+
+	d.cmds = cmds
+	d.stopping.Store(false)
+
+	for _, cmd := range cmds {
+		d.exited.Add(1)
+		go d.supervise(cmd)
+	}
+
+	// Stop the daemons which have been started before the failure
+	if err != nil {
+		d.stop() //nolint:errcheck
+		return err
+	}
+
+	return nil
+}
+
+func (r *Router) stopRoutingDaemon() error {
+	if r.RoutingDaemon == nil || r.RoutingDaemon.cmds == nil {
+		return nil
+	}
+
+	if err := r.RoutingDaemon.stop(); err != nil {
+		return fmt.Errorf("failed to stop routing daemon: %w", err)
+	}
+
+	return nil
+}
+
+// supervise waits for a daemon process and reports if it exits unexpectedly.
+func (d *RoutingDaemon) supervise(cmd *Cmd) {
+	defer d.exited.Done()
+
+	err := cmd.Wait()
+
+	if !d.stopping.Load() {
+		d.router.logger.Error("Routing daemon exited unexpectedly",
+			zap.String("cmd", cmd.Path),
+			zap.Error(err))
+	}
+}
+
+// stop terminates all daemon processes and waits for them to exit.
+func (d *RoutingDaemon) stop() error {
+	d.stopping.Store(true)
+
+	var errs []error
+
+	for _, cmd := range d.cmds {
+		if err := cmd.Process.Signal(syscall.SIGTERM); err != nil && !errors.Is(err, os.ErrProcessDone) {
+			errs = append(errs, fmt.Errorf("failed to stop %s: %w", cmd.Path, err))
+		}
+	}
+
+	d.exited.Wait()
+	d.cmds = nil
+
+	return errors.Join(errs...)
+}
+
+// WaitConverged blocks until the routing daemon has established
+// adjacencies with all of its neighbors or the context is canceled.
+func (r *Router) WaitConverged(ctx context.Context) error {
+	d := r.RoutingDaemon
+	if d == nil {
+		return errNoRoutingDaemon
+	}
+
+	ticker := time.NewTicker(500 * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		var converged bool
+		var err error
+
+		switch d.Suite {
+		case RoutingSuiteFRR:
+			converged, err = d.convergedFRR()
+		case RoutingSuiteBIRD:
+			converged, err = d.convergedBIRD()
+		}
+
+		if err != nil {
+			r.logger.Debug("Failed to query routing daemon", zap.Error(err))
+		} else if converged {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("routing daemon did not converge: %w", ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+// configFile returns the path of a configuration file of the routing daemon
+// as seen from outside of the node.
+func (d *RoutingDaemon) configFile(name string) string {
+	return filepath.Join(d.router.VarPath, "files", d.Suite.ConfigDir(), name)
+}
+
+func (d *RoutingDaemon) writeConfigFile(name string, contents []byte) error {
+	fn := d.configFile(name)
+
+	if err := os.MkdirAll(filepath.Dir(fn), 0o755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	return os.WriteFile(fn, contents, 0o644)
+}
+
+// networks returns the prefixes of all directly connected networks.
+func (d *RoutingDaemon) networks() (nets []*net.IPNet) {
+	for _, i := range d.router.Interfaces {
+		if i.IsLoopback() {
+			continue
+		}
+
+		for _, a := range i.Addresses {
+			nets = append(nets, &net.IPNet{
+				IP:   a.IP.Mask(a.Mask),
+				Mask: a.Mask,
+			})
+		}
+	}
+
+	return nets
+}
+
+// neighbors returns all routers with a routing daemon which share a subnet with us.
+func (d *RoutingDaemon) neighbors() (nbrs []routingNeighbor) {
+	for _, p := range d.router.network.Routers() {
+		if p == d.router || p.RoutingDaemon == nil {
+			continue
+		}
+
+		for _, i := range d.router.Interfaces {
+			for _, a := range i.Addresses {
+				for _, pi := range p.Interfaces {
+					for _, pa := range pi.Addresses {
+						if (a.IP.To4() == nil) != (pa.IP.To4() == nil) || a.IP.Equal(pa.IP) {
+							continue
+						}
+
+						if a.Contains(pa.IP) {
+							nbrs = append(nbrs, routingNeighbor{
+								Router:    p,
+								Interface: i,
+								Address:   pa.IP,
+							})
+						}
+					}
+				}
+			}
+		}
+	}
+
+	return nbrs
+}
+
+// ospfNeighbors returns the number of distinct neighbors which also run OSPF.
+func (d *RoutingDaemon) ospfNeighbors() int {
+	rtrs := map[*Router]any{}
+
+	for _, nbr := range d.neighbors() {
+		if nbr.Router.RoutingDaemon.OSPF && nbr.Address.To4() != nil {
+			rtrs[nbr.Router] = nil
+		}
+	}
+
+	return len(rtrs)
+}
+
+// bgpNeighbors returns all neighbors which also run BGP.
+func (d *RoutingDaemon) bgpNeighbors() (nbrs []routingNeighbor) {
+	for _, nbr := range d.neighbors() {
+		if nbr.Router.RoutingDaemon.ASN != 0 {
+			nbrs = append(nbrs, nbr)
+		}
+	}
+
+	return nbrs
+}
+
+func (r *Router) routerID() net.IP {
+	for _, i := range r.Interfaces {
+		if i.IsLoopback() {
+			continue
+		}
+
+		for _, a := range i.Addresses {
+			if ip := a.IP.To4(); ip != nil {
+				return ip
+			}
+		}
+	}
+
+	return nil
+}
+
+// waitForFile waits until a file has been created by a routing daemon.
+func waitForFile(fn string) error {
+	for range 50 {
+		if _, err := os.Stat(fn); err == nil {
+			return nil
+		}
+
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	return fmt.Errorf("%w: %s", errTimeout, fn)
+}
@@ -0,0 +1,466 @@
+// SPDX-FileCopyrightText: 2023 Steffen Vogel <post@steffenvogel.de>
+// SPDX-License-Identifier: Apache-2.0
+
+package gont
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"syscall"
+	"time"
+
+	nl "github.com/vishvananda/netlink"
+	"go.uber.org/zap"
+)
+
+const (
+	// DefaultRoutingAgentPort is the UDP port on which routing agents exchange their routing tables.
+	DefaultRoutingAgentPort = 5520
+
+	// DefaultRoutingAgentInterval is the interval in which routing agents advertise their routing tables.
+	DefaultRoutingAgentInterval = time.Second
+
+	// RoutingAgentInfinity is the metric of unreachable destinations.
+	RoutingAgentInfinity = 16
+
+	// RouteProtocolRoutingAgent marks routes which have been installed by a routing agent.
+	RouteProtocolRoutingAgent nl.RouteProtocol = 0x47 // 'G'
+)
+
+var errRoutingAgentStarted = errors.New("routing agent has already been started")
+
+type RoutingAgentOption interface {
+	ApplyRoutingAgent(a *RoutingAgent)
+}
+
+// RoutingAgent is a minimal in-process distance-vector routing protocol similar to RIP.
+//
+// Each agent periodically broadcasts its routing table on all IPv4 interfaces of its router
+// which are up. Split horizon with poisoned reverse is used to avoid count-to-infinity problems.
+// Routes are withdrawn if the interface towards the next hop goes down
+// or no advertisement has been received from the next hop for three intervals.
+type RoutingAgent struct {
+	// Options
+	Port     int
+	Interval time.Duration
+
+	router *Router
+	conn   *net.UDPConn
+	table  map[string]*routingAgentRoute
+	mu     sync.Mutex
+	cancel context.CancelFunc
+	done   chan struct{}
+	logger *zap.Logger
+}
+
+// RoutingAgentRoute is an entry of the routing table of a RoutingAgent.
+type RoutingAgentRoute struct {
+	Dst       *net.IPNet
+	Gw        net.IP // Nil for directly connected networks
+	Interface string
+	Metric    int
+}
+
+type routingAgentRoute struct {
+	RoutingAgentRoute
+
+	linkIndex int
+	updated   time.Time
+}
+
+type routingAgentMessage struct {
+	Router string                     `json:"router"`
+	Routes []routingAgentMessageRoute `json:"routes"`
+}
+
+type routingAgentMessageRoute struct {
+	Dst    string `json:"dst"`
+	Metric int    `json:"metric"`
+}
+
+// StartRoutingAgent starts a distance-vector routing agent on the router.
+//
+// The agent discovers interfaces and neighbors at runtime.
+// Hence it can be started before all links of the router have been added.
+func (r *Router) StartRoutingAgent(opts ...Option) (*RoutingAgent, error) {
+	if r.RoutingAgent != nil {
+		return nil, errRoutingAgentStarted
+	}
+
+	a := &RoutingAgent{
+		Port:     DefaultRoutingAgentPort,
+		Interval: DefaultRoutingAgentInterval,
+		router:   r,
+		table:    map[string]*routingAgentRoute{},
+		done:     make(chan struct{}),
+		logger:   r.logger.Named("routing-agent"),
+	}
+
+	for _, opt := range opts {
+		if opt, ok := opt.(RoutingAgentOption); ok {
+			opt.ApplyRoutingAgent(a)
+		}
+	}
+
+	// The socket is bound to the namespace in which it has been created
+	lc := net.ListenConfig{
+		Control: func(_, _ string, c syscall.RawConn) (err error) {
+			if cerr := c.Control(func(fd uintptr) {
+				err = syscall.SetsockoptInt(int(fd), syscall.SOL_SOCKET, syscall.SO_BROADCAST, 1)
+			}); cerr != nil {
+				return cerr
+			}
+
+			return err
+		},
+	}
+
+	if err := r.RunFunc(func() error {
+		pc, err := lc.ListenPacket(context.Background(), "udp4", fmt.Sprintf(":%d", a.Port))
+		if err != nil {
+			return err
+		}
+
+		a.conn = pc.(*net.UDPConn) //nolint:forcetypeassert
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("failed to listen: %w", err)
+	}
+
+	a.logger.Info("Started routing agent",
+		zap.Int("port", a.Port),
+		zap.Duration("interval", a.Interval),
+	)
+
+	var ctx context.Context
+	ctx, a.cancel = context.WithCancel(context.Background())
+
+	go a.receive()
+	go a.run(ctx)
+
+	r.RoutingAgent = a
+
+	return a, nil
+}
+
+// Close stops the agent and withdraws all routes which it has installed.
+func (a *RoutingAgent) Close() error {
+	a.cancel()
+	<-a.done
+
+	if err := a.conn.Close(); err != nil {
+		return fmt.Errorf("failed to close socket: %w", err)
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for key, e := range a.table {
+		a.withdraw(key, e)
+	}
+
+	return nil
+}
+
+// Routes returns a snapshot of the agent's routing table.
+func (a *RoutingAgent) Routes() []RoutingAgentRoute {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	routes := []RoutingAgentRoute{}
+	for _, e := range a.table {
+		routes = append(routes, e.RoutingAgentRoute)
+	}
+
+	return routes
+}
+
+func (a *RoutingAgent) run(ctx context.Context) {
+	defer close(a.done)
+
+	ticker := time.NewTicker(a.Interval)
+	defer ticker.Stop()
+
+	for {
+		intfs, err := a.interfaces()
+		if err != nil {
+			a.logger.Error("Failed to list interfaces", zap.Error(err))
+		} else {
+			a.mu.Lock()
+			a.update(intfs)
+			msgs := a.advertisements(intfs)
+			a.mu.Unlock()
+
+			a.send(msgs)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// interfaces returns the IPv4 addresses of all interfaces which are up.
+func (a *RoutingAgent) interfaces() (map[int][]*net.IPNet, error) {
+	hdl := a.router.NetlinkHandle()
+
+	links, err := hdl.LinkList()
+	if err != nil {
+		return nil, err
+	}
+
+	intfs := map[int][]*net.IPNet{}
+
+	for _, l := range links {
+		attrs := l.Attrs()
+		if attrs.Flags&net.FlagUp == 0 || attrs.Flags&net.FlagLoopback != 0 ||
+			attrs.OperState == nl.OperDown || attrs.OperState == nl.OperLowerLayerDown {
+			continue
+		}
+
+		addrs, err := hdl.AddrList(l, nl.FAMILY_V4)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, addr := range addrs {
+			intfs[attrs.Index] = append(intfs[attrs.Index], addr.IPNet)
+		}
+	}
+
+	return intfs, nil
+}
+
+// update refreshes the directly connected networks
+// and withdraws stale routes or routes via interfaces which are down.
+func (a *RoutingAgent) update(intfs map[int][]*net.IPNet) {
+	connected := map[string]any{}
+
+	for idx, addrs := range intfs {
+		for _, addr := range addrs {
+			dst := &net.IPNet{
+				IP:   addr.IP.Mask(addr.Mask),
+				Mask: addr.Mask,
+			}
+
+			key := dst.String()
+			connected[key] = nil
+
+			a.table[key] = &routingAgentRoute{
+				RoutingAgentRoute: RoutingAgentRoute{
+					Dst:       dst,
+					Interface: a.linkName(idx),
+				},
+				linkIndex: idx,
+				updated:   time.Now(),
+			}
+		}
+	}
+
+	for key, e := range a.table {
+		if e.Gw == nil {
+			if _, ok := connected[key]; !ok {
+				delete(a.table, key)
+			}
+		} else if _, up := intfs[e.linkIndex]; !up || time.Since(e.updated) > 3*a.Interval {
+			a.withdraw(key, e)
+		}
+	}
+}
+
+// advertisements returns the routing table as seen by the neighbors
+// of each interface indexed by the interface's broadcast address.
+func (a *RoutingAgent) advertisements(intfs map[int][]*net.IPNet) map[string]routingAgentMessage {
+	msgs := map[string]routingAgentMessage{}
+
+	for idx, addrs := range intfs {
+		msg := routingAgentMessage{
+			Router: a.router.Name(),
+		}
+
+		for _, e := range a.table {
+			metric := e.Metric
+
+			// Split horizon with poisoned reverse
+			if e.Gw != nil && e.linkIndex == idx {
+				metric = RoutingAgentInfinity
+			}
+
+			msg.Routes = append(msg.Routes, routingAgentMessageRoute{
+				Dst:    e.Dst.String(),
+				Metric: metric,
+			})
+		}
+
+		for _, addr := range addrs {
+			bcast := make(net.IP, net.IPv4len)
+			for i, b := range addr.IP.To4() {
+				bcast[i] = b | ^addr.Mask[i]
+			}
+
+			msgs[bcast.String()] = msg
+		}
+	}
+
+	return msgs
+}
+
+func (a *RoutingAgent) send(msgs map[string]routingAgentMessage) {
+	for bcast, msg := range msgs {
+		buf, err := json.Marshal(msg)
+		if err != nil {
+			a.logger.Error("Failed to encode advertisement", zap.Error(err))
+			continue
+		}
+
+		if _, err := a.conn.WriteToUDP(buf, &net.UDPAddr{
+			IP:   net.ParseIP(bcast),
+			Port: a.Port,
+		}); err != nil {
+			a.logger.Debug("Failed to send advertisement", zap.String("bcast", bcast), zap.Error(err))
+		}
+	}
+}
+
+func (a *RoutingAgent) receive() {
+	buf := make([]byte, 65535)
+
+	for {
+		n, src, err := a.conn.ReadFromUDP(buf)
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				a.logger.Error("Failed to receive advertisement", zap.Error(err))
+			}
+
+			return
+		}
+
+		var msg routingAgentMessage
+		if err := json.Unmarshal(buf[:n], &msg); err != nil {
+			a.logger.Warn("Failed to decode advertisement", zap.Error(err))
+			continue
+		}
+
+		if msg.Router == a.router.Name() {
+			continue // Ignore our own broadcasts
+		}
+
+		a.mu.Lock()
+		a.handle(src.IP, msg)
+		a.mu.Unlock()
+	}
+}
+
+// handle merges the routing table advertised by a neighbor into our own.
+func (a *RoutingAgent) handle(gw net.IP, msg routingAgentMessage) {
+	// Find the connected network via which we reach the neighbor
+	var via *routingAgentRoute
+	for _, e := range a.table {
+		if e.Gw == nil && e.Dst.Contains(gw) {
+			via = e
+			break
+		}
+	}
+
+	if via == nil {
+		return
+	}
+
+	for _, r := range msg.Routes {
+		_, dst, err := net.ParseCIDR(r.Dst)
+		if err != nil {
+			continue
+		}
+
+		key := dst.String()
+		metric := min(r.Metric+1, RoutingAgentInfinity)
+		e, ok := a.table[key]
+
+		switch {
+		case ok && e.Gw == nil:
+			continue // Directly connected networks take precedence
+
+		case ok && e.Gw.Equal(gw):
+			// Always accept updates from the current next hop
+			if metric >= RoutingAgentInfinity {
+				a.withdraw(key, e)
+				continue
+			}
+
+			e.updated = time.Now()
+			if e.Metric == metric {
+				continue
+			}
+
+		case metric >= RoutingAgentInfinity, ok && metric >= e.Metric:
+			continue
+		}
+
+		e = &routingAgentRoute{
+			RoutingAgentRoute: RoutingAgentRoute{
+				Dst:       dst,
+				Gw:        gw,
+				Interface: via.Interface,
+				Metric:    metric,
+			},
+			linkIndex: via.linkIndex,
+			updated:   time.Now(),
+		}
+
+		if err := a.router.NetlinkHandle().RouteReplace(&nl.Route{
+			Dst:       dst,
+			Gw:        gw,
+			LinkIndex: e.linkIndex,
+			Protocol:  RouteProtocolRoutingAgent,
+		}); err != nil {
+			a.logger.Error("Failed to install route", zap.String("dst", key), zap.Error(err))
+			continue
+		}
+
+		a.logger.Debug("Installed route",
+			zap.String("dst", key),
+			zap.Stringer("gw", gw),
+			zap.Int("metric", metric),
+		)
+
+		a.table[key] = e
+	}
+}
+
+// withdraw removes a learned route from the routing table and the kernel.
+func (a *RoutingAgent) withdraw(key string, e *routingAgentRoute) {
+	delete(a.table, key)
+
+	if e.Gw == nil {
+		return
+	}
+
+	if err := a.router.NetlinkHandle().RouteDel(&nl.Route{
+		Dst:      e.Dst,
+		Gw:       e.Gw,
+		Protocol: RouteProtocolRoutingAgent,
+	}); err != nil {
+		a.logger.Debug("Failed to withdraw route", zap.String("dst", key), zap.Error(err))
+	}
+
+	a.logger.Debug("Withdrew route",
+		zap.String("dst", key),
+		zap.Stringer("gw", e.Gw),
+	)
+}
+
+func (a *RoutingAgent) linkName(idx int) string {
+	for _, i := range a.router.Interfaces {
+		if i.Link != nil && i.Link.Attrs().Index == idx {
+			return i.Name
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,104 @@
+// SPDX-FileCopyrightText: 2023 Steffen Vogel <post@steffenvogel.de>
+// SPDX-License-Identifier: Apache-2.0
+
+package gont_test
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	g "cunicu.li/gont/v2/pkg"
+	o "cunicu.li/gont/v2/pkg/options"
+	"github.com/stretchr/testify/require"
+)
+
+// TestRoutingAgentFailover checks that routing agents converge
+// to an alternative path after a link failure
+//
+//	h1 <-> r1 <-----> r3 <-> h2
+//	        \-> r2 <-/
+func TestRoutingAgentFailover(t *testing.T) {
+	n, err := g.NewNetwork(*nname, o.IPv6Disabled(true))
+	require.NoError(t, err, "Failed to create network")
+	defer n.MustClose()
+
+	h1, err := n.AddHost("h1")
+	require.NoError(t, err, "Failed to create host")
+
+	h2, err := n.AddHost("h2")
+	require.NoError(t, err, "Failed to create host")
+
+	routers := []*g.Router{}
+	for _, name := range []string{"r1", "r2", "r3"} {
+		r, err := n.AddRouter(name)
+		require.NoError(t, err, "Failed to create router")
+
+		routers = append(routers, r)
+	}
+
+	r1, r2, r3 := routers[0], routers[1], routers[2]
+
+	for _, link := range [][2]*g.Interface{
+		{
+			g.NewInterface("veth0", h1, o.AddressIP("10.0.1.2/24")),
+			g.NewInterface("veth-h1", r1, o.AddressIP("10.0.1.1/24")),
+		},
+		{
+			g.NewInterface("veth0", h2, o.AddressIP("10.0.2.2/24")),
+			g.NewInterface("veth-h2", r3, o.AddressIP("10.0.2.1/24")),
+		},
+		{
+			g.NewInterface("veth-r3", r1, o.AddressIP("10.0.13.1/24")),
+			g.NewInterface("veth-r1", r3, o.AddressIP("10.0.13.3/24")),
+		},
+		{
+			g.NewInterface("veth-r2", r1, o.AddressIP("10.0.12.1/24")),
+			g.NewInterface("veth-r1", r2, o.AddressIP("10.0.12.2/24")),
+		},
+		{
+			g.NewInterface("veth-r3", r2, o.AddressIP("10.0.23.2/24")),
+			g.NewInterface("veth-r2", r3, o.AddressIP("10.0.23.3/24")),
+		},
+	} {
+		err := n.AddLink(link[0], link[1])
+		require.NoError(t, err, "Failed to add link")
+	}
+
+	err = h1.AddDefaultRoute(net.IPv4(10, 0, 1, 1))
+	require.NoError(t, err, "Failed to add default route")
+
+	err = h2.AddDefaultRoute(net.IPv4(10, 0, 2, 1))
+	require.NoError(t, err, "Failed to add default route")
+
+	for _, r := range routers {
+		_, err := r.StartRoutingAgent(o.RoutingAgentInterval(200 * time.Millisecond))
+		require.NoError(t, err, "Failed to start routing agent")
+	}
+
+	nextHop := func() net.IP {
+		r, err := r1.RouteGet(net.IPv4(10, 0, 2, 2))
+		if err != nil {
+			return nil
+		}
+
+		return r.Gw
+	}
+
+	require.Eventually(t, func() bool {
+		return nextHop().Equal(net.IPv4(10, 0, 13, 3))
+	}, 10*time.Second, 100*time.Millisecond, "Routing agents did not converge")
+
+	_, err = h1.Ping(h2)
+	require.NoError(t, err, "Failed to ping via direct path")
+
+	err = r1.Interface("veth-r3").SetDown()
+	require.NoError(t, err, "Failed to bring interface down")
+
+	require.Eventually(t, func() bool {
+		return nextHop().Equal(net.IPv4(10, 0, 12, 2))
+	}, 10*time.Second, 100*time.Millisecond, "Routing agents did not converge after link failure")
+
+	_, err = h1.Ping(h2)
+	require.NoError(t, err, "Failed to ping via alternative path")
+}
@@ -0,0 +1,161 @@
+// SPDX-FileCopyrightText: 2023 Steffen Vogel <post@steffenvogel.de>
+// SPDX-License-Identifier: Apache-2.0
+
+package gont
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+//nolint:gochecknoglobals
+var birdConfigTemplate = template.Must(template.New("bird").Parse(`# Autogenerated by Gont
+router id {{ .RouterID }};
+
+protocol device {}
+
+protocol direct {
+	ipv4;
+	ipv6;
+}
+
+protocol kernel {
+	ipv4 { export where source != RTS_DEVICE; };
+}
+
+protocol kernel {
+	ipv6 { export where source != RTS_DEVICE; };
+}
+{{ if .OSPF }}
+protocol ospf v2 {
+	ipv4 { import all; export where source = RTS_DEVICE; };
+	area 0 {
+{{- range .OSPFInterfaces }}
+		interface "{{ . }}";
+{{- end }}
+	};
+}
+{{ end }}
+{{- range $i, $nbr := .BGPNeighbors }}
+protocol bgp peer{{ $i }} {
+	local as {{ $.ASN }};
+	neighbor {{ .Address }} as {{ .Router.RoutingDaemon.ASN }};
+	{{ if .Address.To4 }}ipv4{{ else }}ipv6{{ end }} { import all; export where source ~ [ RTS_DEVICE, RTS_BGP ]; };
+}
+{{ end }}`))
+
+type birdConfig struct {
+	*RoutingDaemon
+
+	OSPFInterfaces []string
+	BGPNeighbors   []routingNeighbor
+}
+
+func (d *RoutingDaemon) startBIRD() ([]*Cmd, error) {
+	path, err := exec.LookPath("bird")
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := birdConfig{
+		RoutingDaemon: d,
+		BGPNeighbors:  d.bgpNeighbors(),
+	}
+
+	for _, i := range d.router.Interfaces {
+		for _, a := range i.Addresses {
+			if a.IP.To4() != nil && !i.IsLoopback() {
+				cfg.OSPFInterfaces = append(cfg.OSPFInterfaces, i.Name)
+				break
+			}
+		}
+	}
+
+	buf := &bytes.Buffer{}
+	if err := birdConfigTemplate.Execute(buf, cfg); err != nil {
+		return nil, fmt.Errorf("failed to render configuration: %w", err)
+	}
+
+	if err := d.writeConfigFile("bird.conf", buf.Bytes()); err != nil {
+		return nil, fmt.Errorf("failed to write configuration: %w", err)
+	}
+
+	dir := d.Suite.ConfigDir()
+
+	cmd, err := d.router.Start(path, "-f",
+		"-c", filepath.Join(dir, "bird.conf"),
+		"-s", filepath.Join(dir, "bird.ctl"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to start bird: %w", err)
+	}
+
+	return []*Cmd{cmd}, nil
+}
+
+func (d *RoutingDaemon) convergedBIRD() (bool, error) {
+	if d.OSPF {
+		out, err := d.birdc("show", "ospf", "neighbors")
+		if err != nil {
+			return false, err
+		}
+
+		full := 0
+		for _, fields := range out {
+			if len(fields) > 2 && strings.HasPrefix(fields[2], "Full") {
+				full++
+			}
+		}
+
+		if full < d.ospfNeighbors() {
+			return false, nil
+		}
+	}
+
+	if d.ASN != 0 {
+		out, err := d.birdc("show", "protocols")
+		if err != nil {
+			return false, err
+		}
+
+		established := 0
+		for _, fields := range out {
+			if len(fields) > 5 && fields[1] == "BGP" && fields[5] == "Established" {
+				established++
+			}
+		}
+
+		if established < len(d.bgpNeighbors()) {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+// birdc runs a command via BIRD's control socket and returns the fields of each line of its output.
+func (d *RoutingDaemon) birdc(args ...any) ([][]string, error) {
+	out := &bytes.Buffer{}
+
+	args = append([]any{"-s", filepath.Join(d.Suite.ConfigDir(), "bird.ctl")}, args...)
+
+	c := d.router.Command("birdc", args...)
+	c.StdoutWriters = append(c.StdoutWriters, out)
+
+	if err := c.Run(); err != nil {
+		return nil, fmt.Errorf("failed to run birdc: %w", err)
+	}
+
+	lines := [][]string{}
+
+	s := bufio.NewScanner(out)
+	for s.Scan() {
+		lines = append(lines, strings.Fields(s.Text()))
+	}
+
+	return lines, s.Err()
+}
@@ -0,0 +1,217 @@
+// SPDX-FileCopyrightText: 2023 Steffen Vogel <post@steffenvogel.de>
+// SPDX-License-Identifier: Apache-2.0
+
+package gont
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+// FRRDaemonDir is the directory in which the FRR daemons are installed.
+var FRRDaemonDir = "/usr/lib/frr" //nolint:gochecknoglobals
+
+//nolint:gochecknoglobals
+var frrConfigTemplates = map[string]*template.Template{
+	"zebra": template.Must(template.New("zebra").Parse(`! Autogenerated by Gont
+hostname {{ .Router.Name }}
+log stdout
+`)),
+	"ospfd": template.Must(template.New("ospfd").Parse(`! Autogenerated by Gont
+hostname {{ .Router.Name }}
+log stdout
+!
+router ospf
+ ospf router-id {{ .RouterID }}
+{{- range .Networks }}{{ if .IP.To4 }}
+ network {{ . }} area 0
+{{- end }}{{ end }}
+`)),
+	"bgpd": template.Must(template.New("bgpd").Parse(`! Autogenerated by Gont
+hostname {{ .Router.Name }}
+log stdout
+!
+router bgp {{ .ASN }}
+ bgp router-id {{ .RouterID }}
+ no bgp ebgp-requires-policy
+ no bgp default ipv4-unicast
+{{- range .BGPNeighbors }}
+ neighbor {{ .Address }} remote-as {{ .Router.RoutingDaemon.ASN }}
+{{- end }}
+ !
+ address-family ipv4 unicast
+{{- range .Networks }}{{ if .IP.To4 }}
+  network {{ . }}
+{{- end }}{{ end }}
+{{- range .BGPNeighbors }}{{ if .Address.To4 }}
+  neighbor {{ .Address }} activate
+{{- end }}{{ end }}
+ exit-address-family
+ !
+ address-family ipv6 unicast
+{{- range .Networks }}{{ if not .IP.To4 }}
+  network {{ . }}
+{{- end }}{{ end }}
+{{- range .BGPNeighbors }}{{ if not .Address.To4 }}
+  neighbor {{ .Address }} activate
+{{- end }}{{ end }}
+ exit-address-family
+`)),
+}
+
+type frrConfig struct {
+	*RoutingDaemon
+
+	Router       *Router
+	Networks     []*net.IPNet
+	BGPNeighbors []routingNeighbor
+}
+
+// frrDaemons returns the FRR daemons which are required for the enabled protocols.
+func (d *RoutingDaemon) frrDaemons() []string {
+	daemons := []string{"zebra"}
+
+	if d.OSPF {
+		daemons = append(daemons, "ospfd")
+	}
+
+	if d.ASN != 0 {
+		daemons = append(daemons, "bgpd")
+	}
+
+	return daemons
+}
+
+// startFRR starts all required FRR daemons.
+// On failure, the daemons which have already been started are returned along with the error.
+func (d *RoutingDaemon) startFRR() ([]*Cmd, error) {
+	cfg := frrConfig{
+		RoutingDaemon: d,
+		Router:        d.router,
+		Networks:      d.networks(),
+		BGPNeighbors:  d.bgpNeighbors(),
+	}
+
+	dir := d.Suite.ConfigDir()
+	cmds := []*Cmd{}
+
+	for _, daemon := range d.frrDaemons() {
+		path, err := frrDaemonPath(daemon)
+		if err != nil {
+			return cmds, err
+		}
+
+		buf := &bytes.Buffer{}
+		if err := frrConfigTemplates[daemon].Execute(buf, cfg); err != nil {
+			return cmds, fmt.Errorf("failed to render %s configuration: %w", daemon, err)
+		}
+
+		if err := d.writeConfigFile(daemon+".conf", buf.Bytes()); err != nil {
+			return cmds, fmt.Errorf("failed to write %s configuration: %w", daemon, err)
+		}
+
+		cmd, err := d.router.Start(path,
+			"--config_file", filepath.Join(dir, daemon+".conf"),
+			"--pid_file", filepath.Join(dir, daemon+".pid"),
+			"--socket", filepath.Join(dir, "zserv.api"),
+			"--vty_socket", dir,
+			"--user", "root",
+			"--group", "root")
+		if err != nil {
+			return cmds, fmt.Errorf("failed to start %s: %w", daemon, err)
+		}
+
+		cmds = append(cmds, cmd)
+
+		// Other daemons connect to zebra's socket during their startup
+		if daemon == "zebra" {
+			if err := waitForFile(d.configFile("zserv.api")); err != nil {
+				return cmds, fmt.Errorf("failed to wait for zebra: %w", err)
+			}
+		}
+	}
+
+	return cmds, nil
+}
+
+func (d *RoutingDaemon) convergedFRR() (bool, error) {
+	if d.OSPF {
+		out, err := d.vtysh("show ip ospf neighbor json")
+		if err != nil {
+			return false, err
+		}
+
+		var resp struct {
+			Neighbors map[string][]map[string]any `json:"neighbors"`
+		}
+
+		if err := json.Unmarshal(out, &resp); err != nil {
+			return false, fmt.Errorf("failed to parse OSPF neighbors: %w", err)
+		}
+
+		full := 0
+		for _, adjs := range resp.Neighbors {
+			for _, adj := range adjs {
+				// The name of the state field differs between FRR versions
+				for _, key := range []string{"nbrState", "state"} {
+					if state, ok := adj[key].(string); ok && strings.HasPrefix(state, "Full") {
+						full++
+						break
+					}
+				}
+			}
+		}
+
+		if full < d.ospfNeighbors() {
+			return false, nil
+		}
+	}
+
+	for _, nbr := range d.bgpNeighbors() {
+		out, err := d.vtysh("show bgp neighbors " + nbr.Address.String() + " json")
+		if err != nil {
+			return false, err
+		}
+
+		var resp map[string]struct {
+			State string `json:"bgpState"`
+		}
+
+		if err := json.Unmarshal(out, &resp); err != nil {
+			return false, fmt.Errorf("failed to parse BGP neighbor: %w", err)
+		}
+
+		if resp[nbr.Address.String()].State != "Established" {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+func (d *RoutingDaemon) vtysh(cmd string) ([]byte, error) {
+	out := &bytes.Buffer{}
+
+	c := d.router.Command("vtysh", "--vty_socket", d.Suite.ConfigDir(), "--command", cmd)
+	c.StdoutWriters = append(c.StdoutWriters, out)
+
+	if err := c.Run(); err != nil {
+		return nil, fmt.Errorf("failed to run vtysh: %w", err)
+	}
+
+	return out.Bytes(), nil
+}
+
+func frrDaemonPath(daemon string) (string, error) {
+	if path, err := exec.LookPath(filepath.Join(FRRDaemonDir, daemon)); err == nil {
+		return path, nil
+	}
+
+	return exec.LookPath(daemon)
+}
@@ -0,0 +1,120 @@
+// SPDX-FileCopyrightText: 2023 Steffen Vogel <post@steffenvogel.de>
+// SPDX-License-Identifier: Apache-2.0
+
+package gont_test
+
+import (
+	"context"
+	"net"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	g "cunicu.li/gont/v2/pkg"
+	o "cunicu.li/gont/v2/pkg/options"
+	"github.com/stretchr/testify/require"
+)
+
+// testRoutingDaemon checks that routes are exchanged between two routers
+//
+//	h1 <-> r1 <-> r2 <-> h2
+func testRoutingDaemon(t *testing.T, daemon func(asn uint32) o.RoutingDaemon) {
+	n, err := g.NewNetwork(*nname)
+	require.NoError(t, err, "Failed to create network")
+	defer n.MustClose()
+
+	h1, err := n.AddHost("h1")
+	require.NoError(t, err, "Failed to create host")
+
+	h2, err := n.AddHost("h2")
+	require.NoError(t, err, "Failed to create host")
+
+	r1, err := n.AddRouter("r1", daemon(65001))
+	require.NoError(t, err, "Failed to create router")
+
+	r2, err := n.AddRouter("r2", daemon(65002))
+	require.NoError(t, err, "Failed to create router")
+
+	for _, link := range [][2]*g.Interface{
+		{
+			g.NewInterface("veth0", h1, o.AddressIP("10.0.1.2/24")),
+			g.NewInterface("veth0", r1, o.AddressIP("10.0.1.1/24")),
+		},
+		{
+			g.NewInterface("veth1", r1, o.AddressIP("10.0.0.1/24")),
+			g.NewInterface("veth1", r2, o.AddressIP("10.0.0.2/24")),
+		},
+		{
+			g.NewInterface("veth0", r2, o.AddressIP("10.0.2.1/24")),
+			g.NewInterface("veth0", h2, o.AddressIP("10.0.2.2/24")),
+		},
+	} {
+		err := n.AddLink(link[0], link[1])
+		require.NoError(t, err, "Failed to add link")
+	}
+
+	err = h1.AddDefaultRoute(net.IPv4(10, 0, 1, 1))
+	require.NoError(t, err, "Failed to add default route")
+
+	err = h2.AddDefaultRoute(net.IPv4(10, 0, 2, 1))
+	require.NoError(t, err, "Failed to add default route")
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	for _, r := range []*g.Router{r1, r2} {
+		err := r.StartRoutingDaemon()
+		require.NoError(t, err, "Failed to start routing daemon")
+	}
+
+	for _, r := range []*g.Router{r1, r2} {
+		err := r.WaitConverged(ctx)
+		require.NoError(t, err, "Routing daemon did not converge")
+	}
+
+	require.Eventually(t, func() bool {
+		_, err := h1.Ping(h2)
+		return err == nil
+	}, 30*time.Second, time.Second, "Routes have not been exchanged")
+}
+
+func skipIfMissing(t *testing.T, bins ...string) {
+	for _, bin := range bins {
+		if _, err := exec.LookPath(bin); err != nil {
+			t.Skipf("%s is not installed", bin)
+		}
+	}
+}
+
+func TestRoutingFRROSPF(t *testing.T) {
+	skipIfMissing(t, "vtysh", filepath.Join(g.FRRDaemonDir, "ospfd"))
+
+	testRoutingDaemon(t, func(uint32) o.RoutingDaemon {
+		return o.FRR(o.OSPF)
+	})
+}
+
+func TestRoutingFRRBGP(t *testing.T) {
+	skipIfMissing(t, "vtysh", filepath.Join(g.FRRDaemonDir, "bgpd"))
+
+	testRoutingDaemon(t, func(asn uint32) o.RoutingDaemon {
+		return o.FRR(o.BGP(asn))
+	})
+}
+
+func TestRoutingBIRDOSPF(t *testing.T) {
+	skipIfMissing(t, "bird", "birdc")
+
+	testRoutingDaemon(t, func(uint32) o.RoutingDaemon {
+		return o.BIRD(o.OSPF)
+	})
+}
+
+func TestRoutingBIRDBGP(t *testing.T) {
+	skipIfMissing(t, "bird", "birdc")
+
+	testRoutingDaemon(t, func(asn uint32) o.RoutingDaemon {
+		return o.BIRD(o.BGP(asn))
+	})
+}
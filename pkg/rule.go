@@ -0,0 +1,138 @@
+// SPDX-FileCopyrightText: 2023 Steffen Vogel <post@steffenvogel.de>
+// SPDX-License-Identifier: Apache-2.0
+
+package gont
+
+import (
+	"cmp"
+	"fmt"
+
+	nl "github.com/vishvananda/netlink"
+	"go.uber.org/zap"
+)
+
+type RuleOption interface {
+	ApplyRule(r *nl.Rule)
+}
+
+// AddRule adds a policy routing rule to the node.
+//
+// If the rule neither matches a source nor destination prefix,
+// it is added for all address families enabled in the network.
+func (n *BaseNode) AddRule(opts ...Option) error {
+	for _, r := range n.newRules(opts...) {
+		n.logger.Info("Add rule",
+			zap.Stringer("rule", r),
+			zap.Int("family", r.Family),
+		)
+
+		if err := n.nlHandle.RuleAdd(r); err != nil {
+			return fmt.Errorf("failed to add rule: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// DeleteRule deletes a policy routing rule from the node.
+//
+// The same options as for AddRule() are used to select the rule.
+func (n *BaseNode) DeleteRule(opts ...Option) error {
+	for _, r := range n.newRules(opts...) {
+		n.logger.Info("Delete rule",
+			zap.Stringer("rule", r),
+			zap.Int("family", r.Family),
+		)
+
+		if err := n.nlHandle.RuleDel(r); err != nil {
+			return fmt.Errorf("failed to delete rule: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// newRules constructs a rule per address family from the given options.
+func (n *BaseNode) newRules(opts ...Option) []*nl.Rule {
+	r := nl.NewRule()
+
+	for _, opt := range opts {
+		if opt, ok := opt.(RuleOption); ok {
+			opt.ApplyRule(r)
+		}
+	}
+
+	if r.Family != 0 {
+		return []*nl.Rule{r}
+	}
+
+	if pfx := cmp.Or(r.Src, r.Dst); pfx != nil {
+		if pfx.IP.To4() != nil {
+			r.Family = nl.FAMILY_V4
+		} else {
+			r.Family = nl.FAMILY_V6
+		}
+
+		return []*nl.Rule{r}
+	}
+
+	rules := []*nl.Rule{}
+
+	if !n.network.IPv4Disabled {
+		r4 := *r
+		r4.Family = nl.FAMILY_V4
+		rules = append(rules, &r4)
+	}
+
+	if !n.network.IPv6Disabled {
+		r6 := *r
+		r6.Family = nl.FAMILY_V6
+		rules = append(rules, &r6)
+	}
+
+	return rules
+}
+
+// AddRoutingTable assigns a name to the routing table with the given ID
+// which is only visible within the node.
+//
+// The name takes precedence over a network-wide name assigned via Network.AddRoutingTable().
+func (n *BaseNode) AddRoutingTable(id uint32, name string) error {
+	_, err := n.addRoutingTable(id, name)
+	return err
+}
+
+// addRoutingTable assigns a name to a routing table of the node and
+// reports whether the name has been newly added.
+func (n *BaseNode) addRoutingTable(id uint32, name string) (bool, error) {
+	n.network.iproute2FilesLock.Lock()
+	defer n.network.iproute2FilesLock.Unlock()
+
+	existing, ok := n.RoutingTables[id]
+	if ok {
+		if existing != name {
+			return false, fmt.Errorf("%w: table %d is named '%s'", errRoutingTableNamed, id, existing)
+		}
+
+		return false, nil
+	}
+
+	n.RoutingTables[id] = name
+
+	if err := n.generateRoutingTablesFile(); err != nil {
+		delete(n.RoutingTables, id)
+		return false, err
+	}
+
+	return true, nil
+}
+
+// removeRoutingTable removes the name of a routing table of the node.
+func (n *BaseNode) removeRoutingTable(id uint32) error {
+	n.network.iproute2FilesLock.Lock()
+	defer n.network.iproute2FilesLock.Unlock()
+
+	delete(n.RoutingTables, id)
+
+	return n.generateRoutingTablesFile()
+}
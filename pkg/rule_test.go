@@ -0,0 +1,117 @@
+// SPDX-FileCopyrightText: 2023 Steffen Vogel <post@steffenvogel.de>
+// SPDX-License-Identifier: Apache-2.0
+
+package gont_test
+
+import (
+	"fmt"
+	"net"
+	"testing"
+
+	g "cunicu.li/gont/v2/pkg"
+	o "cunicu.li/gont/v2/pkg/options"
+	"github.com/stretchr/testify/require"
+	nl "github.com/vishvananda/netlink"
+)
+
+// TestRuleSourceRouting checks that a multi-homed host selects
+// its uplink based on the source address of outgoing packets
+//
+//	h1 <-> sw1 <-> h2
+//	 \---> sw2 <-> h3
+func TestRuleSourceRouting(t *testing.T) {
+	n, err := g.NewNetwork(*nname,
+		o.RoutingTable{ID: 100, Name: "wan"})
+	require.NoError(t, err, "Failed to create network")
+	defer n.MustClose()
+
+	sw1, err := n.AddSwitch("sw1")
+	require.NoError(t, err, "Failed to create switch")
+
+	sw2, err := n.AddSwitch("sw2")
+	require.NoError(t, err, "Failed to create switch")
+
+	h1, err := n.AddHost("h1",
+		g.NewInterface("veth0", sw1,
+			o.AddressIP("10.0.1.1/24")),
+		g.NewInterface("veth1", sw2,
+			o.AddressIP("10.0.2.1/24")))
+	require.NoError(t, err, "Failed to create host")
+
+	for i, sw := range []*g.Switch{sw1, sw2} {
+		_, err := n.AddHost(fmt.Sprintf("h%d", i+2),
+			g.NewInterface("veth0", sw,
+				o.AddressIP("10.0.%d.2/24", i+1),
+				o.AddressIP("10.0.3.1/32")))
+		require.NoError(t, err, "Failed to create host")
+	}
+
+	_, dst, err := net.ParseCIDR("10.0.3.0/24")
+	require.NoError(t, err)
+
+	err = h1.AddRoute(&nl.Route{
+		Dst: dst,
+		Gw:  net.IPv4(10, 0, 1, 2),
+	})
+	require.NoError(t, err, "Failed to add route")
+
+	err = h1.AddRoute(&nl.Route{
+		Dst: dst,
+		Gw:  net.IPv4(10, 0, 2, 2),
+	}, o.Table(100))
+	require.NoError(t, err, "Failed to add route")
+
+	routeVia := func(src string) string {
+		out, err := h1.Command("ip", "route", "get", "10.0.3.1", "from", src).CombinedOutput()
+		require.NoError(t, err, "Failed to get route: %s", out)
+		return string(out)
+	}
+
+	require.Contains(t, routeVia("10.0.2.1"), "via 10.0.1.2")
+
+	ruleOpts := []g.Option{
+		o.RuleFromIP("10.0.2.0/24"),
+		o.Table(100),
+		o.RulePriority(100),
+	}
+
+	err = h1.AddRule(ruleOpts...)
+	require.NoError(t, err, "Failed to add rule")
+
+	out, err := h1.Command("ip", "rule", "show").CombinedOutput()
+	require.NoError(t, err, "Failed to list rules: %s", out)
+	require.Contains(t, string(out), "from 10.0.2.0/24 lookup wan")
+
+	require.Contains(t, routeVia("10.0.2.1"), "via 10.0.2.2")
+	require.Contains(t, routeVia("10.0.1.1"), "via 10.0.1.2")
+
+	_, err = h1.Run("ping", "-c", 1, "-W", 1, "-I", "10.0.2.1", "10.0.3.1")
+	require.NoError(t, err, "Failed to ping via secondary uplink")
+
+	err = h1.DeleteRule(ruleOpts...)
+	require.NoError(t, err, "Failed to delete rule")
+
+	require.Contains(t, routeVia("10.0.2.1"), "via 10.0.1.2")
+}
+
+// TestRuleFwMark checks that rules without a prefix are added for all address families
+func TestRuleFwMark(t *testing.T) {
+	n, err := g.NewNetwork(*nname)
+	require.NoError(t, err, "Failed to create network")
+	defer n.MustClose()
+
+	h1, err := n.AddHost("h1")
+	require.NoError(t, err, "Failed to create host")
+
+	err = h1.AddRule(o.RuleFwMark(0x10), o.Table(200))
+	require.NoError(t, err, "Failed to add rule")
+
+	for _, family := range []int{nl.FAMILY_V4, nl.FAMILY_V6} {
+		rules, err := h1.NetlinkHandle().RuleListFiltered(family, &nl.Rule{
+			Table: 200,
+		}, nl.RT_FILTER_TABLE)
+		require.NoError(t, err, "Failed to list rules")
+		require.Len(t, rules, 1)
+		require.EqualValues(t, 0x10, rules[0].Mark)
+	}
+}
@@ -21,6 +21,9 @@ type BridgeOption interface {
 // Switch is an abstraction for a Linux virtual bridge
 type Switch struct {
 	*BaseNode
+
+	// Options
+	SpanningTree SpanningTree
 }
 
 // Options
@@ -70,6 +73,10 @@ func (n *Network) AddSwitch(name string, opts ...Option) (*Switch, error) {
 		zap.String("intf", br.Name),
 	)
 
+	if err := sw.configureSpanningTree(br); err != nil {
+		return nil, err
+	}
+
 	if err := sw.nlHandle.LinkSetUp(br); err != nil {
 		return nil, fmt.Errorf("failed to bring bridge up: %w", err)
 	}
@@ -112,5 +119,9 @@ func (sw *Switch) ConfigureInterface(i *Interface) error {
 		return err
 	}
 
+	if err := sw.configurePort(l, i.BridgePort); err != nil {
+		return fmt.Errorf("failed to configure bridge port: %w", err)
+	}
+
 	return sw.BaseNode.ConfigureInterface(i)
 }
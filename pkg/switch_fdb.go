@@ -0,0 +1,136 @@
+// SPDX-FileCopyrightText: 2023 Steffen Vogel <post@steffenvogel.de>
+// SPDX-License-Identifier: Apache-2.0
+
+package gont
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	nl "github.com/vishvananda/netlink"
+	"go.uber.org/zap"
+	"golang.org/x/sys/unix"
+)
+
+var errMissingPort = errors.New("missing port of FDB entry")
+
+// FDBEntry is an entry of the forwarding database of a Switch.
+type FDBEntry struct {
+	MAC    net.HardwareAddr
+	Port   *Interface
+	VLAN   int
+	Age    time.Duration // Time since the entry has been updated
+	Static bool
+	Local  bool // The MAC address belongs to a port of the switch itself
+}
+
+// FDB returns the entries of the switch's forwarding database.
+func (sw *Switch) FDB() ([]FDBEntry, error) {
+	br, err := sw.nlHandle.LinkByName(bridgeInterfaceName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find bridge interface: %w", err)
+	}
+
+	neighs, err := sw.nlHandle.NeighList(0, unix.AF_BRIDGE)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list FDB entries: %w", err)
+	}
+
+	entries := []FDBEntry{}
+
+	for _, n := range neighs {
+		// Skip entries of the ports themselves which are not managed by the bridge
+		if n.MasterIndex != br.Attrs().Index {
+			continue
+		}
+
+		port := sw.port(n.LinkIndex)
+		if port == nil {
+			continue
+		}
+
+		entries = append(entries, FDBEntry{
+			MAC:    n.HardwareAddr,
+			Port:   port,
+			VLAN:   n.Vlan,
+			Age:    time.Duration(n.Updated) * time.Second / userHZ,
+			Static: n.State&(unix.NUD_NOARP|unix.NUD_PERMANENT) != 0,
+			Local:  n.State&unix.NUD_PERMANENT != 0,
+		})
+	}
+
+	return entries, nil
+}
+
+// AddStaticFDB adds a static entry to the forwarding database of the switch.
+// An existing entry for the same MAC address and VLAN is replaced.
+func (sw *Switch) AddStaticFDB(e FDBEntry) error {
+	if e.Port == nil || e.Port.Link == nil {
+		return errMissingPort
+	}
+
+	sw.logger.Info("Adding static FDB entry",
+		zap.Stringer("mac", e.MAC),
+		zap.Any("port", e.Port),
+		zap.Int("vlan", e.VLAN),
+	)
+
+	return sw.nlHandle.NeighSet(fdbNeigh(e))
+}
+
+// DeleteFDB removes an entry from the forwarding database of the switch.
+func (sw *Switch) DeleteFDB(e FDBEntry) error {
+	if e.Port == nil || e.Port.Link == nil {
+		return errMissingPort
+	}
+
+	sw.logger.Info("Deleting FDB entry",
+		zap.Stringer("mac", e.MAC),
+		zap.Any("port", e.Port),
+		zap.Int("vlan", e.VLAN),
+	)
+
+	return sw.nlHandle.NeighDel(fdbNeigh(e))
+}
+
+// FlushFDB removes all dynamically learned entries from the forwarding database of the switch.
+func (sw *Switch) FlushFDB() error {
+	br, err := sw.nlHandle.LinkByName(bridgeInterfaceName)
+	if err != nil {
+		return fmt.Errorf("failed to find bridge interface: %w", err)
+	}
+
+	sw.logger.Info("Flushing FDB")
+
+	if err := sw.setBridgeAttrs(br, map[int][]byte{
+		unix.IFLA_BR_FDB_FLUSH: {},
+	}); err != nil {
+		return fmt.Errorf("failed to flush FDB: %w", err)
+	}
+
+	return nil
+}
+
+// port returns the interface of the switch with the given link index.
+func (sw *Switch) port(idx int) *Interface {
+	for _, i := range sw.Interfaces {
+		if i.Link != nil && i.Link.Attrs().Index == idx {
+			return i
+		}
+	}
+
+	return nil
+}
+
+func fdbNeigh(e FDBEntry) *nl.Neigh {
+	return &nl.Neigh{
+		LinkIndex:    e.Port.Link.Attrs().Index,
+		Family:       unix.AF_BRIDGE,
+		State:        unix.NUD_NOARP,
+		Flags:        unix.NTF_MASTER,
+		HardwareAddr: e.MAC,
+		Vlan:         e.VLAN,
+	}
+}
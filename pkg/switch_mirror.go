@@ -0,0 +1,114 @@
+// SPDX-FileCopyrightText: 2023 Steffen Vogel <post@steffenvogel.de>
+// SPDX-License-Identifier: Apache-2.0
+
+package gont
+
+import (
+	"errors"
+	"fmt"
+
+	nl "github.com/vishvananda/netlink"
+	"go.uber.org/zap"
+	"golang.org/x/sys/unix"
+)
+
+var (
+	errNoSwitchPort   = errors.New("interface is not a port of the switch")
+	errMirrorLoop     = errors.New("mirror destination can not be a source at the same time")
+	errNoMirrorSource = errors.New("mirror has no source ports")
+)
+
+// MirrorDirection selects the traffic of a source port which is mirrored.
+type MirrorDirection int
+
+const (
+	MirrorIngress MirrorDirection = 1 << iota // Frames received by the switch on the source port
+	MirrorEgress                              // Frames sent by the switch out of the source port
+	MirrorBoth    = MirrorIngress | MirrorEgress
+)
+
+func (d MirrorDirection) String() string {
+	switch d {
+	case MirrorIngress:
+		return "ingress"
+	case MirrorEgress:
+		return "egress"
+	case MirrorBoth:
+		return "both"
+	default:
+		return fmt.Sprintf("unknown (%d)", d)
+	}
+}
+
+// Mirror copies all frames of the source ports to the destination port (SPAN)
+//
+// This is implemented by tc matchall filters with mirred actions attached to
+// the clsact qdisc of each source port. A host connected to the destination port
+// receives the mirrored frames on its own interface, e.g. for running an IDS.
+func (sw *Switch) Mirror(src []*Interface, dst *Interface, dir MirrorDirection) error {
+	if len(src) == 0 {
+		return errNoMirrorSource
+	}
+
+	if dst.Node != sw {
+		return fmt.Errorf("%w: %s", errNoSwitchPort, dst)
+	}
+
+	for _, s := range src {
+		if s.Node != sw {
+			return fmt.Errorf("%w: %s", errNoSwitchPort, s)
+		} else if s == dst {
+			return errMirrorLoop
+		}
+	}
+
+	sw.logger.Info("Adding port mirror",
+		zap.Any("src", src),
+		zap.Any("dst", dst),
+		zap.Stringer("direction", dir),
+	)
+
+	for _, s := range src {
+		if err := sw.nlHandle.QdiscReplace(&nl.Clsact{
+			QdiscAttrs: nl.QdiscAttrs{
+				LinkIndex: s.Link.Attrs().Index,
+				Handle:    nl.MakeHandle(0xffff, 0),
+				Parent:    nl.HANDLE_CLSACT,
+			},
+		}); err != nil {
+			return fmt.Errorf("failed to add clsact qdisc: %w", err)
+		}
+
+		for _, d := range []MirrorDirection{MirrorIngress, MirrorEgress} {
+			if dir&d == 0 {
+				continue
+			}
+
+			parent := uint32(nl.HANDLE_MIN_INGRESS)
+			if d == MirrorEgress {
+				parent = nl.HANDLE_MIN_EGRESS
+			}
+
+			if err := sw.nlHandle.FilterAdd(&nl.MatchAll{
+				FilterAttrs: nl.FilterAttrs{
+					LinkIndex: s.Link.Attrs().Index,
+					Parent:    parent,
+					Protocol:  unix.ETH_P_ALL,
+				},
+				Actions: []nl.Action{
+					&nl.MirredAction{
+						ActionAttrs: nl.ActionAttrs{
+							Action: nl.TC_ACT_PIPE,
+						},
+						MirredAction: nl.TCA_EGRESS_MIRROR,
+						Ifindex:      dst.Link.Attrs().Index,
+					},
+				},
+			}); err != nil {
+				return fmt.Errorf("failed to add %s mirror filter: %w", d, err)
+			}
+		}
+	}
+
+	return nil
+}
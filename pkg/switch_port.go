@@ -0,0 +1,164 @@
+// SPDX-FileCopyrightText: 2023 Steffen Vogel <post@steffenvogel.de>
+// SPDX-License-Identifier: Apache-2.0
+
+package gont
+
+import (
+	"errors"
+	"fmt"
+
+	nl "github.com/vishvananda/netlink"
+	nlenc "github.com/vishvananda/netlink/nl"
+	"golang.org/x/sys/unix"
+)
+
+// PortState is the spanning tree state of a bridge port.
+type PortState uint8
+
+const (
+	PortStateDisabled PortState = iota
+	PortStateListening
+	PortStateLearning
+	PortStateForwarding
+	PortStateBlocking
+)
+
+func (s PortState) String() string {
+	switch s {
+	case PortStateDisabled:
+		return "disabled"
+	case PortStateListening:
+		return "listening"
+	case PortStateLearning:
+		return "learning"
+	case PortStateForwarding:
+		return "forwarding"
+	case PortStateBlocking:
+		return "blocking"
+	default:
+		return fmt.Sprintf("unknown (%d)", s)
+	}
+}
+
+// BridgePort holds the settings of an interface which is attached to a Switch.
+//
+// When set for an interface of a Host which is connected to a Switch,
+// they are applied to the port at the Switch's side of the link.
+type BridgePort struct {
+	Cost           *uint32
+	Priority       *uint16
+	Learning       *bool
+	UnicastFlood   *bool
+	MulticastFlood *bool
+	BroadcastFlood *bool
+}
+
+// PortStates returns the spanning tree state of each port of the switch indexed by interface name.
+func (sw *Switch) PortStates() (map[string]PortState, error) {
+	states := map[string]PortState{}
+
+	if err := sw.RunFunc(func() error {
+		req := nlenc.NewNetlinkRequest(unix.RTM_GETLINK, unix.NLM_F_DUMP)
+		req.AddData(nlenc.NewIfInfomsg(unix.AF_BRIDGE))
+
+		msgs, err := req.Execute(unix.NETLINK_ROUTE, unix.RTM_NEWLINK)
+		if err != nil && !errors.Is(err, nl.ErrDumpInterrupted) {
+			return err
+		}
+
+		for _, m := range msgs {
+			ifi := nlenc.DeserializeIfInfomsg(m)
+
+			attrs, err := nlenc.ParseRouteAttr(m[ifi.Len():])
+			if err != nil {
+				return err
+			}
+
+			var name string
+			var state *PortState
+
+			for _, attr := range attrs {
+				switch attr.Attr.Type {
+				case unix.IFLA_IFNAME:
+					name = string(attr.Value[:len(attr.Value)-1])
+
+				case unix.IFLA_PROTINFO | unix.NLA_F_NESTED:
+					infos, err := nlenc.ParseRouteAttr(attr.Value)
+					if err != nil {
+						return err
+					}
+
+					for _, info := range infos {
+						if info.Attr.Type == nlenc.IFLA_BRPORT_STATE {
+							s := PortState(info.Value[0])
+							state = &s
+						}
+					}
+				}
+			}
+
+			if state != nil && name != bridgeInterfaceName {
+				states[name] = *state
+			}
+		}
+
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("failed to get port states: %w", err)
+	}
+
+	return states, nil
+}
+
+// configurePort applies the bridge port settings of an interface.
+func (sw *Switch) configurePort(l nl.Link, p BridgePort) error {
+	attrs := map[int][]byte{}
+
+	if p.Cost != nil {
+		attrs[nlenc.IFLA_BRPORT_COST] = nlenc.Uint32Attr(*p.Cost)
+	}
+
+	if p.Priority != nil {
+		attrs[nlenc.IFLA_BRPORT_PRIORITY] = nlenc.Uint16Attr(*p.Priority)
+	}
+
+	for attr, v := range map[int]*bool{
+		nlenc.IFLA_BRPORT_LEARNING:      p.Learning,
+		nlenc.IFLA_BRPORT_UNICAST_FLOOD: p.UnicastFlood,
+		nlenc.IFLA_BRPORT_MCAST_FLOOD:   p.MulticastFlood,
+		nlenc.IFLA_BRPORT_BCAST_FLOOD:   p.BroadcastFlood,
+	} {
+		if v != nil {
+			attrs[attr] = boolAttr(*v)
+		}
+	}
+
+	if len(attrs) == 0 {
+		return nil
+	}
+
+	protinfo := nlenc.NewRtAttr(unix.IFLA_PROTINFO|unix.NLA_F_NESTED, nil)
+	for attr, val := range attrs {
+		protinfo.AddRtAttr(attr, val)
+	}
+
+	req := nlenc.NewNetlinkRequest(unix.RTM_SETLINK, unix.NLM_F_ACK)
+
+	msg := nlenc.NewIfInfomsg(unix.AF_BRIDGE)
+	msg.Index = int32(l.Attrs().Index) //nolint:gosec
+	req.AddData(msg)
+	req.AddData(protinfo)
+
+	return sw.RunFunc(func() error {
+		_, err := req.Execute(unix.NETLINK_ROUTE, 0)
+		return err
+	})
+}
+
+func boolAttr(v bool) []byte {
+	if v {
+		return []byte{1}
+	}
+
+	return []byte{0}
+}
@@ -0,0 +1,116 @@
+// SPDX-FileCopyrightText: 2023 Steffen Vogel <post@steffenvogel.de>
+// SPDX-License-Identifier: Apache-2.0
+
+package gont
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	nl "github.com/vishvananda/netlink"
+	nlenc "github.com/vishvananda/netlink/nl"
+	"go.uber.org/zap"
+	"golang.org/x/sys/unix"
+)
+
+const (
+	// userHZ is the resolution of the bridge timers exchanged via Netlink.
+	userHZ = 100
+
+	// bridgeSTPHelper is executed by the kernel to start a user-space STP daemon.
+	bridgeSTPHelper = "/sbin/bridge-stp"
+)
+
+var errRSTPUnsupported = errors.New("RSTP requires a user-space STP daemon like mstpd installed as " + bridgeSTPHelper)
+
+// SpanningTree configures the spanning tree protocol (STP) of a Switch.
+//
+// The Linux kernel implements IEEE 802.1D STP.
+// If a user-space STP daemon like mstpd is installed as /sbin/bridge-stp,
+// the kernel delegates to it instead, which enables RSTP.
+type SpanningTree struct {
+	Enabled      bool
+	Rapid        bool    // Require RSTP support via a user-space STP daemon
+	Priority     *uint16 // Bridge priority (lower values are preferred as root bridge)
+	ForwardDelay *time.Duration
+	MaxAge       *time.Duration
+}
+
+// configureSpanningTree applies the STP settings to the bridge interface.
+func (sw *Switch) configureSpanningTree(br nl.Link) error {
+	stp := sw.SpanningTree
+
+	if !stp.Enabled && stp.Priority == nil && stp.ForwardDelay == nil && stp.MaxAge == nil {
+		return nil
+	}
+
+	if stp.Rapid {
+		if _, err := os.Stat(bridgeSTPHelper); err != nil {
+			return errRSTPUnsupported
+		}
+	}
+
+	sw.logger.Info("Configuring spanning tree protocol",
+		zap.Bool("enabled", stp.Enabled),
+		zap.Bool("rapid", stp.Rapid),
+	)
+
+	attrs := map[int][]byte{}
+
+	if stp.ForwardDelay != nil {
+		attrs[unix.IFLA_BR_FORWARD_DELAY] = nlenc.Uint32Attr(clockTicks(*stp.ForwardDelay))
+	}
+
+	if stp.MaxAge != nil {
+		attrs[unix.IFLA_BR_MAX_AGE] = nlenc.Uint32Attr(clockTicks(*stp.MaxAge))
+	}
+
+	if stp.Priority != nil {
+		attrs[unix.IFLA_BR_PRIORITY] = nlenc.Uint16Attr(*stp.Priority)
+	}
+
+	var state uint32
+	if stp.Enabled {
+		state = 1
+	}
+
+	attrs[unix.IFLA_BR_STP_STATE] = nlenc.Uint32Attr(state)
+
+	if err := sw.setBridgeAttrs(br, attrs); err != nil {
+		return fmt.Errorf("failed to configure spanning tree protocol: %w", err)
+	}
+
+	return nil
+}
+
+// setBridgeAttrs changes attributes of the bridge interface.
+//
+// The kernel applies timers before enabling STP regardless of the order of the attributes.
+func (sw *Switch) setBridgeAttrs(br nl.Link, attrs map[int][]byte) error {
+	req := nlenc.NewNetlinkRequest(unix.RTM_NEWLINK, unix.NLM_F_ACK)
+
+	msg := nlenc.NewIfInfomsg(unix.AF_UNSPEC)
+	msg.Index = int32(br.Attrs().Index) //nolint:gosec
+	req.AddData(msg)
+
+	linkInfo := nlenc.NewRtAttr(unix.IFLA_LINKINFO, nil)
+	linkInfo.AddRtAttr(nlenc.IFLA_INFO_KIND, nlenc.NonZeroTerminated(br.Type()))
+
+	data := linkInfo.AddRtAttr(nlenc.IFLA_INFO_DATA, nil)
+	for attr, val := range attrs {
+		data.AddRtAttr(attr, val)
+	}
+
+	req.AddData(linkInfo)
+
+	return sw.RunFunc(func() error {
+		_, err := req.Execute(unix.NETLINK_ROUTE, 0)
+		return err
+	})
+}
+
+func clockTicks(d time.Duration) uint32 {
+	return uint32(d * userHZ / time.Second) //nolint:gosec
+}
@@ -4,7 +4,9 @@
 package gont_test
 
 import (
+	"net"
 	"testing"
+	"time"
 
 	g "cunicu.li/gont/v2/pkg"
 	o "cunicu.li/gont/v2/pkg/options"
@@ -44,3 +46,214 @@ func TestPingCascadedSwitches(t *testing.T) {
 	err = g.TestConnectivity(h1, h2)
 	require.NoError(t, err, "Failed to check connectivity")
 }
+
+// TestSwitchSTP checks that the spanning tree protocol
+// resolves a loop in a redundant switch topology
+//
+//	h1 <-> sw1 <-> sw2 <-> h2
+//	        ^       ^
+//	        \-> sw3 /
+func TestSwitchSTP(t *testing.T) {
+	n, err := g.NewNetwork(*nname)
+	require.NoError(t, err, "Failed to create network")
+	defer n.MustClose()
+
+	stpOpts := []g.Option{
+		o.STP(true),
+		o.ForwardDelay(2 * time.Second),
+	}
+
+	sw1, err := n.AddSwitch("sw1", append(stpOpts, o.BridgePriority(0))...)
+	require.NoError(t, err, "Failed to add switch")
+
+	sw2, err := n.AddSwitch("sw2", stpOpts...)
+	require.NoError(t, err, "Failed to add switch")
+
+	sw3, err := n.AddSwitch("sw3", stpOpts...)
+	require.NoError(t, err, "Failed to add switch")
+
+	for _, link := range [][2]*g.Interface{
+		{g.NewInterface("br-sw2", sw1), g.NewInterface("br-sw1", sw2)},
+		{g.NewInterface("br-sw3", sw1), g.NewInterface("br-sw1", sw3)},
+		{g.NewInterface("br-sw3", sw2, o.PortCost(1000)), g.NewInterface("br-sw2", sw3, o.PortCost(1000))},
+	} {
+		err := n.AddLink(link[0], link[1])
+		require.NoError(t, err, "Failed to add link")
+	}
+
+	h1, err := n.AddHost("h1",
+		g.NewInterface("veth0", sw1,
+			o.AddressIP("10.0.0.1/24")))
+	require.NoError(t, err, "Failed to add host")
+
+	h2, err := n.AddHost("h2",
+		g.NewInterface("veth0", sw2,
+			o.AddressIP("10.0.0.2/24")))
+	require.NoError(t, err, "Failed to add host")
+
+	// The link between sw2 and sw3 has the highest cost and gets blocked
+	require.Eventually(t, func() bool {
+		blocking := 0
+
+		for _, sw := range []*g.Switch{sw1, sw2, sw3} {
+			states, err := sw.PortStates()
+			if err != nil {
+				return false
+			}
+
+			for _, state := range states {
+				switch state {
+				case g.PortStateBlocking:
+					blocking++
+				case g.PortStateForwarding:
+				default:
+					return false
+				}
+			}
+		}
+
+		return blocking == 1
+	}, 30*time.Second, 500*time.Millisecond, "Spanning tree did not converge")
+
+	states, err := sw1.PortStates()
+	require.NoError(t, err, "Failed to get port states")
+	require.Equal(t, g.PortStateForwarding, states["br-sw2"])
+	require.Equal(t, g.PortStateForwarding, states["br-sw3"])
+
+	err = g.TestConnectivity(h1, h2)
+	require.NoError(t, err, "Failed to check connectivity")
+}
+
+// TestSwitchFDB checks learned and static entries of the forwarding database
+//
+//	h1 <-> sw <-> h2
+//	       ^
+//	       \-> h3
+func TestSwitchFDB(t *testing.T) {
+	n, err := g.NewNetwork(*nname)
+	require.NoError(t, err, "Failed to create network")
+	defer n.MustClose()
+
+	sw, err := n.AddSwitch("sw")
+	require.NoError(t, err, "Failed to add switch")
+
+	h1, err := n.AddHost("h1",
+		g.NewInterface("veth0", sw,
+			o.AddressIP("10.0.0.1/24")))
+	require.NoError(t, err, "Failed to add host")
+
+	h2, err := n.AddHost("h2",
+		g.NewInterface("veth0", sw,
+			o.AddressIP("10.0.0.2/24"),
+			o.PortLearning(false)))
+	require.NoError(t, err, "Failed to add host")
+
+	h3, err := n.AddHost("h3",
+		g.NewInterface("veth0", sw,
+			o.AddressIP("10.0.0.3/24")))
+	require.NoError(t, err, "Failed to add host")
+
+	err = g.TestConnectivity(h1, h2, h3)
+	require.NoError(t, err, "Failed to check connectivity")
+
+	mac1 := h1.Interface("veth0").Link.Attrs().HardwareAddr
+	mac2 := h2.Interface("veth0").Link.Attrs().HardwareAddr
+	mac3 := h3.Interface("veth0").Link.Attrs().HardwareAddr
+	port1 := sw.Interface("veth-h1")
+	port2 := sw.Interface("veth-h2")
+
+	lookup := func(mac net.HardwareAddr) *g.FDBEntry {
+		entries, err := sw.FDB()
+		require.NoError(t, err, "Failed to get FDB")
+
+		for _, e := range entries {
+			if e.MAC.String() == mac.String() {
+				return &e
+			}
+		}
+
+		return nil
+	}
+
+	e := lookup(mac1)
+	require.NotNil(t, e, "Missing learned FDB entry")
+	require.Equal(t, port1, e.Port)
+	require.False(t, e.Static)
+
+	require.Nil(t, lookup(mac2), "Learning has not been disabled")
+
+	// Simulate a MAC move
+	err = sw.AddStaticFDB(g.FDBEntry{
+		MAC:  mac1,
+		Port: port2,
+	})
+	require.NoError(t, err, "Failed to add static FDB entry")
+
+	e = lookup(mac1)
+	require.NotNil(t, e, "Missing static FDB entry")
+	require.Equal(t, port2, e.Port)
+	require.True(t, e.Static)
+
+	e = lookup(mac3)
+	require.NotNil(t, e, "Missing learned FDB entry")
+	require.False(t, e.Static)
+
+	err = sw.FlushFDB()
+	require.NoError(t, err, "Failed to flush FDB")
+
+	require.NotNil(t, lookup(mac1), "Static FDB entry has been flushed")
+	require.Nil(t, lookup(mac3), "Learned FDB entry has not been flushed")
+}
+
+// TestSwitchMirror checks that frames of a port are mirrored to an IDS host
+//
+//	h1 <-> sw <-> h2
+//	       ^
+//	       \-> ids
+func TestSwitchMirror(t *testing.T) {
+	n, err := g.NewNetwork(*nname)
+	require.NoError(t, err, "Failed to create network")
+	defer n.MustClose()
+
+	sw, err := n.AddSwitch("sw")
+	require.NoError(t, err, "Failed to add switch")
+
+	h1, err := n.AddHost("h1",
+		g.NewInterface("veth0", sw,
+			o.AddressIP("10.0.0.1/24")))
+	require.NoError(t, err, "Failed to add host")
+
+	h2, err := n.AddHost("h2",
+		g.NewInterface("veth0", sw,
+			o.AddressIP("10.0.0.2/24")))
+	require.NoError(t, err, "Failed to add host")
+
+	ids, err := n.AddHost("ids",
+		g.NewInterface("veth0", sw))
+	require.NoError(t, err, "Failed to add host")
+
+	// Populate the FDB so that unicast frames are not flooded to the IDS
+	err = g.TestConnectivity(h1, h2)
+	require.NoError(t, err, "Failed to check connectivity")
+
+	err = sw.Mirror([]*g.Interface{sw.Interface("veth-h1")}, sw.Interface("veth-ids"), g.MirrorBoth)
+	require.NoError(t, err, "Failed to add mirror")
+
+	rxPackets := func() uint64 {
+		l, err := ids.NetlinkHandle().LinkByName("veth0")
+		require.NoError(t, err, "Failed to get link")
+
+		return l.Attrs().Statistics.RxPackets
+	}
+
+	before := rxPackets()
+
+	_, err = h1.PingWithOptions(h2, "ip", 5, 5*time.Second, 100*time.Millisecond, false)
+	require.NoError(t, err, "Failed to ping")
+
+	// Echo requests and replies
+	require.GreaterOrEqual(t, rxPackets()-before, uint64(10), "Frames have not been mirrored")
+
+	err = sw.Mirror([]*g.Interface{sw.Interface("veth-ids")}, sw.Interface("veth-ids"), g.MirrorIngress)
+	require.Error(t, err, "Mirrored port to itself")
+}
@@ -41,6 +41,9 @@ type Tracer struct {
 	Callbacks []trace.EventCallback
 	Captures  []*Capture
 
+	// Expose the events via a UNIX socket in the runtime directory of the network
+	Expose bool
+
 	closables     []io.Closer
 	files         []*os.File
 	packetSources []*traceEventPacketSource
@@ -83,13 +86,9 @@ func (t *Tracer) start() error {
 
 	// Captures
 	for _, c := range t.Captures {
-		_, ps, err := c.startTrace()
-		if err != nil {
-			return fmt.Errorf("failed to start capturing traces: %w", err)
+		if err := t.startCapture(c); err != nil {
+			return err
 		}
-
-		t.packetSources = append(t.packetSources, ps)
-		t.closables = append(t.closables, ps)
 	}
 
 	t.stop = make(chan any)
@@ -99,6 +98,37 @@ func (t *Tracer) start() error {
 	return nil
 }
 
+func (t *Tracer) startCapture(c *Capture) error {
+	_, ps, err := c.startTrace()
+	if err != nil {
+		return fmt.Errorf("failed to start capturing traces: %w", err)
+	}
+
+	t.packetSources = append(t.packetSources, ps)
+	t.closables = append(t.closables, ps)
+
+	return nil
+}
+
+// expose serves the events as PCAPng stream via a UNIX socket.
+// Viewers can connect at any time to the socket as it is
+// backed by a capture listener.
+func (t *Tracer) expose(path string) error {
+	c := NewCapture()
+	c.ListenAddrs = []string{"unix:" + path}
+	c.FlushEach = 1
+
+	t.Captures = append(t.Captures, c)
+	t.closables = append(t.closables, c)
+
+	// Tracer has already been started
+	if t.stop != nil {
+		return t.startCapture(c)
+	}
+
+	return nil
+}
+
 func (t *Tracer) Start() error {
 	if t.stop == nil {
 		if err := t.start(); err != nil {
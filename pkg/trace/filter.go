@@ -0,0 +1,26 @@
+// SPDX-FileCopyrightText: 2023 Steffen Vogel <post@steffenvogel.de>
+// SPDX-License-Identifier: Apache-2.0
+
+package trace
+
+import "net"
+
+// FilterLog is the data of an event emitted for
+// a packet which has been logged by a filter rule.
+//
+//nolint:tagliatelle
+type FilterLog struct {
+	Rule            string `cbor:"rule" json:"rule"`
+	Node            string `cbor:"node" json:"node"`
+	Hook            string `cbor:"hook" json:"hook"`
+	InputInterface  string `cbor:"iif,omitempty" json:"iif,omitempty"`
+	OutputInterface string `cbor:"oif,omitempty" json:"oif,omitempty"`
+	Length          int    `cbor:"len" json:"len"`
+
+	// Decoded 5-tuple
+	Protocol        string `cbor:"proto,omitempty" json:"proto,omitempty"`
+	Source          net.IP `cbor:"src,omitempty" json:"src,omitempty"`
+	Destination     net.IP `cbor:"dst,omitempty" json:"dst,omitempty"`
+	SourcePort      uint16 `cbor:"sport,omitempty" json:"sport,omitempty"`
+	DestinationPort uint16 `cbor:"dport,omitempty" json:"dport,omitempty"`
+}
@@ -20,6 +20,7 @@ import (
 	co "cunicu.li/gont/v2/pkg/options/capture"
 	to "cunicu.li/gont/v2/pkg/options/trace"
 	"cunicu.li/gont/v2/pkg/trace"
+	"github.com/gopacket/gopacket/pcapgo"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/zap"
 )
@@ -103,6 +104,42 @@ func TestTraceInSameProcess(t *testing.T) {
 	require.Equal(t, event.Function, "cunicu.li/gont/v2/pkg_test.TestTraceInSameProcess", "Wrong function name")
 }
 
+func TestTraceExpose(t *testing.T) {
+	t1 := g.NewTracer(
+		to.Expose(true),
+	)
+
+	n, err := g.NewNetwork(*nname, t1)
+	require.NoError(t, err, "Failed to create network")
+	defer n.MustClose()
+
+	err = t1.Start()
+	require.NoError(t, err, "Failed to start tracer")
+
+	require.True(t, g.TraceExposed(n.Name), "Tracer is not exposed")
+
+	conn, err := g.DialTrace(n.Name)
+	require.NoError(t, err, "Failed to connect to tracer")
+	defer conn.Close()
+
+	err = conn.SetReadDeadline(time.Now().Add(10 * time.Second))
+	require.NoError(t, err, "Failed to set deadline")
+
+	rd, err := pcapgo.NewNgReader(conn, pcapgo.DefaultNgReaderOptions)
+	require.NoError(t, err, "Failed to read section header")
+
+	err = trace.Print("This is an exposed trace message")
+	require.NoError(t, err, "Failed to write trace")
+
+	data, _, err := rd.ReadPacketData()
+	require.NoError(t, err, "Failed to read trace event")
+
+	event := trace.Event{}
+	err = event.Unmarshal(data)
+	require.NoError(t, err, "Failed to decode trace event")
+	require.Equal(t, "This is an exposed trace message", event.Message, "Wrong message")
+}
+
 func TestTraceLog(t *testing.T) {
 	var event *trace.Event
 
@@ -160,6 +197,7 @@ func TestTraceWithCapture(t *testing.T) {
 
 	c1 := g.NewCapture(
 		co.ListenAddr(*captureSocketAddr),
+		co.WaitForViewer(true),
 	)
 
 	t1 := g.NewTracer(
@@ -0,0 +1,120 @@
+// SPDX-FileCopyrightText: 2023 Steffen Vogel <post@steffenvogel.de>
+// SPDX-License-Identifier: Apache-2.0
+
+package gont
+
+import (
+	"fmt"
+	"syscall"
+
+	nl "github.com/vishvananda/netlink"
+	"go.uber.org/zap"
+)
+
+// VRF is a Linux virtual routing and forwarding device.
+//
+// Interfaces which are enslaved to a VRF use the VRF's routing table
+// instead of the main one. This allows emulating multiple routers
+// with overlapping address spaces within a single node.
+//
+// Pass the VRF as an option to NewInterface() to enslave the interface,
+// or to BaseNode.AddRoute() and BaseNode.AddRule() to use the VRF's routing table.
+type VRF struct {
+	Name  string
+	Table uint32
+	Link  nl.Link
+
+	node *Router
+}
+
+func (v *VRF) ApplyInterface(i *Interface) {
+	i.VRF = v
+}
+
+func (v *VRF) ApplyRoute(r *nl.Route) {
+	r.Table = int(v.Table)
+}
+
+func (v *VRF) ApplyRule(r *nl.Rule) {
+	r.Table = int(v.Table)
+}
+
+func (v *VRF) String() string {
+	return fmt.Sprintf("%s/%s", v.node, v.Name)
+}
+
+// AddVRF adds a new VRF device to the router which uses the routing table with the given ID.
+func (r *Router) AddVRF(name string, table uint32) (*VRF, error) {
+	if len(name) > syscall.IFNAMSIZ-1 {
+		return nil, fmt.Errorf("%w: too long. max_len=%d", ErrInvalidName, syscall.IFNAMSIZ-1)
+	}
+
+	r.logger.Info("Adding new VRF",
+		zap.String("name", name),
+		zap.Uint32("table", table),
+	)
+
+	added, err := r.addRoutingTable(table, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to name routing table: %w", err)
+	}
+
+	l, err := r.addVRFLink(name, table)
+	if err != nil {
+		if added {
+			if err := r.removeRoutingTable(table); err != nil {
+				r.logger.Warn("Failed to remove routing table name", zap.Error(err))
+			}
+		}
+
+		return nil, err
+	}
+
+	v := &VRF{
+		Name:  name,
+		Table: table,
+		Link:  l,
+		node:  r,
+	}
+
+	r.VRFs = append(r.VRFs, v)
+
+	return v, nil
+}
+
+func (r *Router) addVRFLink(name string, table uint32) (nl.Link, error) {
+	if err := r.nlHandle.LinkAdd(&nl.Vrf{
+		LinkAttrs: nl.LinkAttrs{
+			Name: name,
+		},
+		Table: table,
+	}); err != nil {
+		return nil, fmt.Errorf("failed to add VRF: %w", err)
+	}
+
+	l, err := r.nlHandle.LinkByName(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find interface %s: %w", name, err)
+	}
+
+	if err := r.nlHandle.LinkSetUp(l); err != nil {
+		if err := r.nlHandle.LinkDel(l); err != nil {
+			r.logger.Warn("Failed to delete VRF", zap.Error(err))
+		}
+
+		return nil, fmt.Errorf("failed to bring VRF up: %w", err)
+	}
+
+	return l, nil
+}
+
+// VRF returns the VRF of the router with the given name.
+func (r *Router) VRF(name string) *VRF {
+	for _, v := range r.VRFs {
+		if v.Name == name {
+			return v
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,206 @@
+// SPDX-FileCopyrightText: 2023 Steffen Vogel <post@steffenvogel.de>
+// SPDX-License-Identifier: Apache-2.0
+
+package gont_test
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	g "cunicu.li/gont/v2/pkg"
+	"cunicu.li/gont/v2/pkg/match"
+	o "cunicu.li/gont/v2/pkg/options"
+	fo "cunicu.li/gont/v2/pkg/options/filters"
+	"github.com/stretchr/testify/require"
+	nl "github.com/vishvananda/netlink"
+)
+
+// TestVRF checks that a router can forward traffic between
+// two pairs of hosts with overlapping address spaces using VRFs
+//
+//	h1 <-> r1[red]  <-> h2
+//	h3 <-> r1[blue] <-> h4
+func TestVRF(t *testing.T) {
+	n, err := g.NewNetwork(*nname)
+	require.NoError(t, err, "Failed to create network")
+	defer n.MustClose()
+
+	r1, err := n.AddRouter("r1")
+	require.NoError(t, err, "Failed to create router")
+
+	red, err := r1.AddVRF("red", 10)
+	require.NoError(t, err, "Failed to create VRF")
+
+	blue, err := r1.AddVRF("blue", 20)
+	require.NoError(t, err, "Failed to create VRF")
+
+	require.Equal(t, blue, r1.VRF("blue"))
+
+	hosts := []*g.Host{}
+	for i, vrf := range []*g.VRF{red, red, blue, blue} {
+		h, err := n.AddHost(fmt.Sprintf("h%d", i+1))
+		require.NoError(t, err, "Failed to create host")
+
+		subnet := i%2 + 1
+
+		err = n.AddLink(
+			g.NewInterface("veth0", h,
+				o.AddressIP("10.0.%d.2/24", subnet)),
+			g.NewInterface(fmt.Sprintf("veth-h%d", i+1), r1, vrf,
+				o.AddressIP("10.0.%d.1/24", subnet)))
+		require.NoError(t, err, "Failed to add link")
+
+		err = h.AddDefaultRoute(net.IPv4(10, 0, byte(subnet), 1))
+		require.NoError(t, err, "Failed to add default route")
+
+		hosts = append(hosts, h)
+	}
+
+	_, err = hosts[0].Ping(hosts[1])
+	require.NoError(t, err, "Failed to ping between hosts in red VRF")
+
+	_, err = hosts[2].Ping(hosts[3])
+	require.NoError(t, err, "Failed to ping between hosts in blue VRF")
+
+	_, dst, err := net.ParseCIDR("10.0.3.0/24")
+	require.NoError(t, err)
+
+	err = r1.AddRoute(&nl.Route{
+		Dst: dst,
+		Gw:  net.IPv4(10, 0, 2, 2),
+	}, blue)
+	require.NoError(t, err, "Failed to add route to VRF")
+
+	routes, err := r1.NetlinkHandle().RouteListFiltered(nl.FAMILY_V4, &nl.Route{
+		Table: int(blue.Table),
+		Dst:   dst,
+	}, nl.RT_FILTER_TABLE|nl.RT_FILTER_DST)
+	require.NoError(t, err, "Failed to list routes")
+	require.Len(t, routes, 1)
+
+	routes, err = r1.NetlinkHandle().RouteListFiltered(nl.FAMILY_V4, &nl.Route{
+		Table: int(red.Table),
+		Dst:   dst,
+	}, nl.RT_FILTER_TABLE|nl.RT_FILTER_DST)
+	require.NoError(t, err, "Failed to list routes")
+	require.Empty(t, routes)
+}
+
+// TestVRFRoutingTableNames checks that VRFs of different routers
+// can assign different names to the same routing table while a
+// single router can not assign conflicting names.
+func TestVRFRoutingTableNames(t *testing.T) {
+	n, err := g.NewNetwork(*nname)
+	require.NoError(t, err, "Failed to create network")
+	defer n.MustClose()
+
+	r1, err := n.AddRouter("r1")
+	require.NoError(t, err, "Failed to create router")
+
+	r2, err := n.AddRouter("r2")
+	require.NoError(t, err, "Failed to create router")
+
+	_, err = r1.AddVRF("red", 10)
+	require.NoError(t, err, "Failed to create VRF")
+
+	_, err = r2.AddVRF("blue", 10)
+	require.NoError(t, err, "Failed to create VRF with different table name on other router")
+
+	_, err = r1.AddVRF("green", 10)
+	require.Error(t, err, "Created VRF with conflicting table name")
+	require.Nil(t, r1.VRF("green"))
+
+	require.Equal(t, "red", r1.RoutingTables[10])
+	require.Equal(t, "blue", r2.RoutingTables[10])
+	require.Empty(t, n.RoutingTables)
+
+	// A failing VRF creation does not leave its table name behind
+	_, err = r1.AddVRF("red", 20)
+	require.Error(t, err, "Created VRF with duplicate name")
+	require.NotContains(t, r1.RoutingTables, uint32(20))
+}
+
+// TestVRFCaptureFilter checks captures and interface filters
+// on interfaces which are enslaved to a VRF
+//
+//	h1 <-> r1[red] <-> h2
+//
+// Forwarded packets match the enslaved output interface while
+// packets sent by the router itself match the VRF device in the output hook.
+func TestVRFCaptureFilter(t *testing.T) {
+	c := g.NewCapture()
+
+	n, err := g.NewNetwork(*nname, c)
+	require.NoError(t, err, "Failed to create network")
+	defer n.MustClose()
+
+	r1, err := n.AddRouter("r1")
+	require.NoError(t, err, "Failed to create router")
+
+	red, err := r1.AddVRF("red", 10)
+	require.NoError(t, err, "Failed to create VRF")
+
+	hosts := []*g.Host{}
+	for i := 1; i <= 2; i++ {
+		h, err := n.AddHost(fmt.Sprintf("h%d", i))
+		require.NoError(t, err, "Failed to create host")
+
+		err = n.AddLink(
+			g.NewInterface("veth0", h,
+				o.AddressIP("10.0.%d.2/24", i)),
+			g.NewInterface(fmt.Sprintf("veth-h%d", i), r1, red,
+				o.AddressIP("10.0.%d.1/24", i)))
+		require.NoError(t, err, "Failed to add link")
+
+		err = h.AddDefaultRoute(net.IPv4(10, 0, byte(i), 1))
+		require.NoError(t, err, "Failed to add default route")
+
+		hosts = append(hosts, h)
+	}
+
+	h1, h2 := hosts[0], hosts[1]
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// Captures on enslaved interfaces contain the forwarded packets
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		h1.Ping(h2) //nolint:errcheck
+	}()
+
+	_, err = c.Expect(ctx, match.ICMPv4().From(h1).To(h2).Interface("r1/veth-h2"))
+	require.NoError(t, err, "Failed to capture forwarded packet")
+
+	// Forwarded packets match the enslaved output interface
+	r := fo.Rule().Forward().OutputInterface("veth-h2").ICMPType(8).Drop()
+	hdl := r1.Filter.AddRule(r.Hook, r.Exprs...)
+
+	err = r1.Filter.Flush()
+	require.NoError(t, err, "Failed to flush rules")
+
+	_, err = h1.PingWithOptions(h2, "ip", 1, time.Second, time.Second, false)
+	require.Error(t, err, "Succeeded to ping h2")
+
+	err = r1.Filter.DeleteRule(hdl)
+	require.NoError(t, err, "Failed to delete rule")
+
+	// Packets sent by the router within the VRF match the VRF device
+	r = fo.Rule().Output().OutputInterface(red.Name).ICMPType(8).Drop()
+	hdl = r1.Filter.AddRule(r.Hook, r.Exprs...)
+
+	err = r1.Filter.Flush()
+	require.NoError(t, err, "Failed to flush rules")
+
+	_, err = r1.Run("ping", "-c", "1", "-W", "1", "-I", red.Name, "10.0.2.2")
+	require.Error(t, err, "Succeeded to ping h2 from VRF")
+
+	err = r1.Filter.DeleteRule(hdl)
+	require.NoError(t, err, "Failed to delete rule")
+
+	_, err = r1.Run("ping", "-c", "1", "-W", "1", "-I", red.Name, "10.0.2.2")
+	require.NoError(t, err, "Failed to ping h2 from VRF")
+}